@@ -1 +1,41 @@
 package buildtemplates
+
+import (
+	"dockflow/internal/service/filesystem"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// 仓库没有 Dockerfile 时按检测到的技术栈使用的构建模板
+// 本文件是 Go 源文件，go 的模板因此命名为 Dockerfile.golang
+//
+//go:embed Dockerfile.java Dockerfile.gradle Dockerfile.golang Dockerfile.node-page Dockerfile.node-service Dockerfile.php Dockerfile.python
+var templates embed.FS
+
+var (
+	ErrTemplateNotExist = errors.New("build template not exist")
+)
+
+// Dockerfile 读取技术栈对应的模板
+// 优先使用主机上的 filesystem.BuildDockerfilePath<stack>，便于按需修改模板
+func Dockerfile(stack string) ([]byte, error) {
+	data, err := os.ReadFile(filesystem.BuildDockerfilePath + stack)
+	if err == nil {
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	name := stack
+	if name == "go" {
+		name = "golang"
+	}
+	data, err = templates.ReadFile("Dockerfile." + name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotExist, stack)
+	}
+	return data, nil
+}
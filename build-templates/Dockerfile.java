@@ -1,23 +1,25 @@
 ARG JAVA_VERSION=21
 ARG MAVEN_VERSION=3.9.9
-ARG TARGET_NAME=app.jar
-ARG APP_PORT=8080
 
 FROM maven:${MAVEN_VERSION}-eclipse-temurin-${JAVA_VERSION}-alpine AS builder
 
-WORKDIR /app
+# 多模块项目为 mvn -B package -DskipTests -pl <module> -am
+ARG BUILD_CMD="mvn -B package -DskipTests"
+ARG CACHE_ID=dockflow
 
-COPY pom.xml .
-RUN mvn -B dependency:go-offline
+WORKDIR /app
 
-COPY src ./src
-RUN mvn -B package -DskipTests
+COPY ./ ./
+RUN --mount=type=cache,id=${CACHE_ID}-maven,target=/root/.m2 ${BUILD_CMD}
 
 FROM eclipse-temurin:${JAVA_VERSION}-jre-alpine
 
+ARG TARGET_PATH=target/app.jar
+ARG APP_PORT=8080
+
 WORKDIR /app
 
-COPY --from=builder /app/target/${TARGET_NAME} /app/app.jar
+COPY --from=builder /app/${TARGET_PATH} /app/app.jar
 
 RUN addgroup -S app && adduser -S app -G app
 USER app
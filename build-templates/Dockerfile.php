@@ -0,0 +1,39 @@
+ARG PHP_VERSION=8.2
+
+# =========================
+# Composer
+# =========================
+FROM composer:2 AS vendor
+
+ARG CACHE_ID=dockflow
+
+WORKDIR /app
+
+COPY ./ ./
+
+RUN --mount=type=cache,id=${CACHE_ID}-composer,target=/tmp/cache \
+    composer install --no-dev --no-interaction --no-progress --prefer-dist --ignore-platform-reqs --optimize-autoloader
+
+# =========================
+# Runtime
+# =========================
+FROM php:${PHP_VERSION}-apache
+
+ARG DOC_ROOT=public
+ARG APP_PORT=80
+
+ENV APACHE_DOCUMENT_ROOT=/var/www/html/${DOC_ROOT}
+
+# 监听第一个端口，document root 指向 DOC_ROOT
+RUN PORT="${APP_PORT%% *}" \
+ && sed -ri "s/Listen 80$/Listen ${PORT}/" /etc/apache2/ports.conf \
+ && sed -ri "s/<VirtualHost \*:80>/<VirtualHost *:${PORT}>/" /etc/apache2/sites-available/000-default.conf \
+ && sed -ri "s!/var/www/html!\${APACHE_DOCUMENT_ROOT}!g" /etc/apache2/sites-available/*.conf /etc/apache2/apache2.conf \
+ && a2enmod rewrite \
+ && docker-php-ext-install pdo_mysql
+
+WORKDIR /var/www/html
+
+COPY --from=vendor --chown=www-data:www-data /app ./
+
+EXPOSE ${APP_PORT}
@@ -3,12 +3,18 @@ package main
 import (
 	"context"
 	"dockflow/internal/cli"
+	"dockflow/internal/config"
+	"dockflow/internal/service/api"
 	"dockflow/internal/service/monitor"
+	"dockflow/internal/service/queue"
+	"dockflow/internal/service/secret"
 	"dockflow/internal/service/webhook"
+	"dockflow/internal/usecase"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -27,12 +33,55 @@ func runCLI() {
 func runDaemon() {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	gitService := webhook.NewGitService()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalln("[dockflow] load config error:", err)
+	}
+
+	usecase.RecoverInterruptedDeployments()
+
+	deployQueue := queue.NewDeployQueue(cfg.Daemon.DeployConcurrency, usecase.RunDeployJob)
+	deployQueue.Start(ctx)
+
+	apiToken, err := secret.Resolve(cfg.Daemon.APIToken)
+	if err != nil {
+		log.Fatalln("[dockflow] resolve api_token error:", err)
+	}
+
+	apiServer, err := api.NewServer(api.Options{
+		Listen: cfg.Daemon.APIListen,
+		Token:  apiToken,
+		Queue:  deployQueue,
+	})
+	if err != nil {
+		log.Fatalln("[dockflow] api error:", err)
+	}
+	if err := apiServer.Start(ctx); err != nil {
+		log.Fatalln("[dockflow] api error:", err)
+	}
+
+	gitService := webhook.NewGitService(deployQueue)
 	webhookServer := webhook.NewServer(":8090", gitService)
 	webhookServer.Start(ctx)
 
 	go monitor.ListenDockerEvents(ctx)
 
+	reconcileEvery, err := cfg.Daemon.ReconcileEvery()
+	if err != nil {
+		log.Fatalln("[dockflow] invalid reconcile_interval:", err)
+	}
+	if reconcileEvery > 0 {
+		go runReconciler(ctx, reconcileEvery)
+	}
+
+	gcEvery, err := cfg.Daemon.GCEvery()
+	if err != nil {
+		log.Fatalln("[dockflow] invalid gc_interval:", err)
+	}
+	if gcEvery > 0 {
+		go runGC(ctx, gcEvery)
+	}
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
 
@@ -40,3 +89,55 @@ func runDaemon() {
 	cancel()
 	log.Println("dockflow daemon stopped")
 }
+
+// runReconciler 定期让 docker 收敛到 namespace 状态
+func runReconciler(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	log.Println("[reconcile] started, interval", every)
+	for {
+		select {
+		case <-ticker.C:
+			report, err := usecase.Reconcile(usecase.ReconcileOptions{})
+			if err != nil {
+				log.Println("[reconcile][error]", err)
+				continue
+			}
+			for _, event := range report.Events {
+				log.Printf("[reconcile] %s %s/%s %s %s: %s",
+					event.Action, event.Namespace, event.Name, event.Version, event.Kind, event.Detail,
+				)
+			}
+		case <-ctx.Done():
+			log.Println("[reconcile] stopped")
+			return
+		}
+	}
+}
+
+// runGC 定期按保留策略回收旧版本的容器、镜像与部署记录
+func runGC(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	log.Println("[gc] started, interval", every)
+	for {
+		select {
+		case <-ticker.C:
+			report, err := usecase.GC(usecase.GCOptions{})
+			if err != nil {
+				log.Println("[gc][error]", err)
+				continue
+			}
+			for _, item := range report.Items {
+				log.Printf("[gc] %s %s/%s %s %s removed=%t %s",
+					item.Kind, item.Namespace, item.App, item.Version, item.Ref, item.Removed, item.Detail,
+				)
+			}
+		case <-ctx.Done():
+			log.Println("[gc] stopped")
+			return
+		}
+	}
+}
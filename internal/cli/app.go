@@ -1,25 +1,33 @@
 package cli
 
 import (
+	"context"
 	"dockflow/internal/domain"
 	"dockflow/internal/usecase"
+	"dockflow/internal/util"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	rootCmd.AddCommand(appCmd)
-	appCmd.AddCommand(appCreateCmd, appListCmd, appRemoveCmd, appDeployCmd, appLogCmd, appStatusCmd)
+	appCmd.AddCommand(appCreateCmd, appListCmd, appRemoveCmd, appDeployCmd, appLogCmd, appStatusCmd, appRollbackCmd, appHistoryCmd, appBuildLogCmd, appUpdateCmd)
 
 	appCreateCmd.Flags().Float64("cpu", 1, "CPU limit (cores)")
 	appCreateCmd.Flags().Int("memory", 1, "Memory limit (GB)")
 	appCreateCmd.Flags().String("repo", "", "Git repository url")
 	appCreateCmd.Flags().String("token", "", "Git access token")
-	// appCreateCmd.Flags().String("platform", "", "deploy platform")
-	// appCreateCmd.Flags().String("build-args", "", "build args for platform, json {\"NODE_VERSION\":\"20\",\"BUILD_CMD\":\"npm run build\",\"DIST_DIR\":\"./dist1\"}")
+	appCreateCmd.Flags().String("platform", "", "Build platform, e.g. linux/arm64 (default: the daemon platform)")
+	appCreateCmd.Flags().Int("keep-versions", 0, "Number of recent versions kept by gc (default: daemon keep_versions)")
 	appCreateCmd.Flags().String(
 		"trigger-type",
 		"branch",
@@ -45,15 +53,56 @@ func init() {
 		"app url, format: host:containerPort",
 	)
 
+	// build arg：key=value，可传多次，覆盖检测到的模板参数
+	appCreateCmd.Flags().StringArray(
+		"build-arg",
+		[]string{},
+		"Build arg for every deploy, format: KEY=VALUE (visible in the image history, do not pass secrets)",
+	)
+
+	appCreateCmd.Flags().String("health-path", "", "Health check HTTP path, e.g. /health (empty: no health check)")
+	appCreateCmd.Flags().String("health-port", "", "Health check container port, default first url port (required without --url)")
+	appCreateCmd.Flags().String("health-interval", "10s", "Health check interval")
+	appCreateCmd.Flags().String("health-timeout", "3s", "Health check timeout")
+	appCreateCmd.Flags().Int("health-retries", 3, "Consecutive failures needed to report unhealthy")
+	appCreateCmd.Flags().String("health-start-period", "0s", "Health check start period")
+
 	appDeployCmd.Flags().String("branch", "", "")
 	appDeployCmd.Flags().String("commit", "", "")
 	appDeployCmd.Flags().String("tag", "", "")
+	appDeployCmd.Flags().String("platform", "", "Build platform for this deploy only, e.g. linux/arm64")
+	appDeployCmd.Flags().StringArray("build-arg", []string{}, "Build arg for this deploy only, format: KEY=VALUE (visible in the image history, do not pass secrets)")
+
+	appLogCmd.Flags().String("version", "", "Deploy version, default all versions")
+	appLogCmd.Flags().BoolP("follow", "f", false, "Follow log output")
+	appLogCmd.Flags().String("tail", "all", "Number of lines to show from the end of the logs")
+	appLogCmd.Flags().String("since", "", "Show logs since timestamp or relative (e.g. 10m)")
+
+	appStatusCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+
+	appRollbackCmd.Flags().String("to", "", "Version to rollback to, default previous successful version")
+
+	appHistoryCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+
+	appBuildLogCmd.Flags().String("version", "", "Build version, default most recent build")
+
+	appUpdateCmd.Flags().Float64("cpu", 0, "CPU limit (cores)")
+	appUpdateCmd.Flags().Int("memory", 0, "Memory limit (GB)")
+	appUpdateCmd.Flags().String("trigger-type", "", "Trigger type: branch or tag")
+	appUpdateCmd.Flags().String("trigger-rule", "", "Trigger rule: branch name or tag pattern")
+	appUpdateCmd.Flags().StringArray("env", []string{}, "Add or change environment variable, format: KEY=VALUE")
+	appUpdateCmd.Flags().StringArray("env-rm", []string{}, "Remove environment variable by KEY")
+	appUpdateCmd.Flags().StringArray("url", []string{}, "Add or change app url, format: host:containerPort")
+	appUpdateCmd.Flags().StringArray("url-rm", []string{}, "Remove app url by host, its routes stop immediately")
+	appUpdateCmd.Flags().Int("keep-versions", 0, "Number of recent versions kept by gc, 0 uses the daemon default")
+	appUpdateCmd.Flags().Bool("restart", false, "Restart latest with the current image to apply the changes")
 }
 
 var (
 	ErrRepoCantBlank  = errors.New("app repo url can't be blank")
 	ErrTokenCantBlank = errors.New("app repo token can't be blank")
 	ErrUrlCantBlank   = errors.New("app external url can't be blank")
+	ErrAppNotHealthy  = errors.New("app has missing or stopped containers")
 )
 
 var appCmd = &cobra.Command{
@@ -80,12 +129,9 @@ var appCreateCmd = &cobra.Command{
 		envFlags, _ := cmd.Flags().GetStringArray("env")
 		urlFlags, _ := cmd.Flags().GetStringArray("url")
 
-		// buildArgsStr, _ := cmd.Flags().GetString("build-args")
-		// var buildArgsMap map[string]*string
-		// err := json.Unmarshal([]byte(buildArgsStr), &buildArgsMap)
-		// if err != nil {
-		// 	return fmt.Errorf("build args must be json")
-		// }
+		buildArgFlags, _ := cmd.Flags().GetStringArray("build-arg")
+		platform, _ := cmd.Flags().GetString("platform")
+		keepVersions, _ := cmd.Flags().GetInt("keep-versions")
 
 		// ---------- basic validate ----------
 		if repo == "" {
@@ -110,16 +156,9 @@ var appCreateCmd = &cobra.Command{
 		}
 
 		// ---------- env ----------
-		envs := make([]domain.Env, 0, len(envFlags))
-		for _, item := range envFlags {
-			parts := strings.SplitN(item, "=", 2)
-			if len(parts) != 2 {
-				return fmt.Errorf("invalid env format: %s (expect KEY=VALUE)", item)
-			}
-			envs = append(envs, domain.Env{
-				Key:   parts[0],
-				Value: parts[1],
-			})
+		envs, err := parseEnvFlags(envFlags)
+		if err != nil {
+			return err
 		}
 
 		// ---------- url ----------
@@ -127,16 +166,21 @@ var appCreateCmd = &cobra.Command{
 			return fmt.Errorf("at least one --url is required")
 		}
 
-		urls := make([]domain.AppURL, 0, len(urlFlags))
-		for _, item := range urlFlags {
-			parts := strings.Split(item, ":")
-			if len(parts) != 2 {
-				return fmt.Errorf("invalid url format: %s (expect host:port)", item)
-			}
-			urls = append(urls, domain.AppURL{
-				Host: parts[0],
-				Port: parts[1],
-			})
+		urls, err := parseURLFlags(urlFlags)
+		if err != nil {
+			return err
+		}
+
+		// ---------- health ----------
+		health, err := parseHealthFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		// ---------- build arg ----------
+		buildArgs, err := parseBuildArgFlags(buildArgFlags)
+		if err != nil {
+			return err
 		}
 
 		// ---------- ServiceSpec ----------
@@ -150,15 +194,159 @@ var appCreateCmd = &cobra.Command{
 			Trigger:   trigger,
 			Envs:      envs,
 			URLs:      urls,
-			// BuildArg:  buildArgsMap,
-			// Platform:  platform,
+			Health:    health,
+			BuildArg:  buildArgs,
+			Platform:  platform,
+		}
+		if keepVersions != 0 {
+			spec.Retention = &domain.Retention{KeepVersions: keepVersions}
 		}
 
-		err := usecase.CreateApp(spec)
+		result, err := usecase.CreateApp(spec)
 		if err != nil {
 			return err
 		}
 
+		if result.WebhookSecret != "" {
+			fmt.Printf("app [%s] created, webhook secret: %s\n", name, result.WebhookSecret)
+		}
+		return nil
+	},
+}
+
+func parseEnvFlags(items []string) ([]domain.Env, error) {
+	envs := make([]domain.Env, 0, len(items))
+	for _, item := range items {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid env format: %s (expect KEY=VALUE)", item)
+		}
+		envs = append(envs, domain.Env{
+			Key:   parts[0],
+			Value: parts[1],
+		})
+	}
+	return envs, nil
+}
+
+// parseBuildArgFlags 同一个 key 传多次时后者生效
+func parseBuildArgFlags(items []string) (map[string]*string, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	args := make(map[string]*string, len(items))
+	for _, item := range items {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || !util.ValidEnvKey(parts[0]) {
+			return nil, fmt.Errorf("invalid build arg format: %s (expect KEY=VALUE)", item)
+		}
+		args[parts[0]] = &parts[1]
+	}
+	return args, nil
+}
+
+func parseURLFlags(items []string) ([]domain.AppURL, error) {
+	urls := make([]domain.AppURL, 0, len(items))
+	for _, item := range items {
+		parts := strings.Split(item, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid url format: %s (expect host:port)", item)
+		}
+		urls = append(urls, domain.AppURL{
+			Host: parts[0],
+			Port: parts[1],
+		})
+	}
+	return urls, nil
+}
+
+func parseHealthFlags(cmd *cobra.Command) (*domain.HealthCheck, error) {
+	path, _ := cmd.Flags().GetString("health-path")
+	if path == "" {
+		return nil, nil
+	}
+
+	port, _ := cmd.Flags().GetString("health-port")
+	interval, _ := cmd.Flags().GetString("health-interval")
+	timeout, _ := cmd.Flags().GetString("health-timeout")
+	retries, _ := cmd.Flags().GetInt("health-retries")
+	startPeriod, _ := cmd.Flags().GetString("health-start-period")
+
+	health := &domain.HealthCheck{
+		Path:        path,
+		Port:        port,
+		Interval:    interval,
+		Timeout:     timeout,
+		Retries:     retries,
+		StartPeriod: startPeriod,
+	}
+	if err := health.Validate(); err != nil {
+		return nil, err
+	}
+	return health, nil
+}
+
+var appUpdateCmd = &cobra.Command{
+	Use:   "update <namespace> <name>",
+	Short: "Update app settings without recreating it",
+	Long: "Update cpu, memory, trigger, env and urls of an app. The webhook and deploy history are kept.\n" +
+		"Without --restart the running latest keeps the old settings until the next deploy.",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opt := usecase.UpdateAppOptions{
+			Namespace: args[0],
+			Name:      args[1],
+		}
+
+		// ---------- resources ----------
+		if cmd.Flags().Changed("cpu") {
+			cpu, _ := cmd.Flags().GetFloat64("cpu")
+			opt.CPU = &cpu
+		}
+		if cmd.Flags().Changed("memory") {
+			memory, _ := cmd.Flags().GetInt("memory")
+			opt.Memory = &memory
+		}
+
+		// ---------- retention ----------
+		if cmd.Flags().Changed("keep-versions") {
+			keepVersions, _ := cmd.Flags().GetInt("keep-versions")
+			opt.KeepVersions = &keepVersions
+		}
+
+		// ---------- trigger ----------
+		opt.TriggerType, _ = cmd.Flags().GetString("trigger-type")
+		opt.TriggerRule, _ = cmd.Flags().GetString("trigger-rule")
+
+		// ---------- env / url ----------
+		envFlags, _ := cmd.Flags().GetStringArray("env")
+		urlFlags, _ := cmd.Flags().GetStringArray("url")
+		opt.UnsetEnvs, _ = cmd.Flags().GetStringArray("env-rm")
+		opt.RemoveURLs, _ = cmd.Flags().GetStringArray("url-rm")
+		opt.Restart, _ = cmd.Flags().GetBool("restart")
+
+		var err error
+		if opt.SetEnvs, err = parseEnvFlags(envFlags); err != nil {
+			return err
+		}
+		if opt.AddURLs, err = parseURLFlags(urlFlags); err != nil {
+			return err
+		}
+
+		result, err := usecase.UpdateApp(opt)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case result.Restarted:
+			fmt.Printf("app [%s] updated, latest restarted with version [%s]\n", args[1], result.Version)
+		case result.App.NeedsRedeploy:
+			fmt.Printf("app [%s] updated, deploy or run `app update --restart` to apply the changes\n", args[1])
+		default:
+			fmt.Printf("app [%s] updated\n", args[1])
+		}
 		return nil
 	},
 }
@@ -192,6 +380,13 @@ var appDeployCmd = &cobra.Command{
 		branch, _ := cmd.Flags().GetString("branch")
 		commit, _ := cmd.Flags().GetString("commit")
 		tag, _ := cmd.Flags().GetString("tag")
+		buildArgFlags, _ := cmd.Flags().GetStringArray("build-arg")
+		platform, _ := cmd.Flags().GetString("platform")
+
+		buildArgs, err := parseBuildArgFlags(buildArgFlags)
+		if err != nil {
+			return err
+		}
 
 		opt := usecase.DeployAppOptions{
 			Namespace: namespace,
@@ -199,18 +394,220 @@ var appDeployCmd = &cobra.Command{
 			Branch:    branch,
 			Commit:    commit,
 			Tag:       tag,
+			BuildArgs: buildArgs,
+			Platform:  platform,
+		}
+
+		err = usecase.DeployApp(opt)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+var appLogCmd = &cobra.Command{
+	Use:   "logs <namespace> <name>",
+	Short: "show app logs",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, _ := cmd.Flags().GetString("version")
+		follow, _ := cmd.Flags().GetBool("follow")
+		tail, _ := cmd.Flags().GetString("tail")
+		since, _ := cmd.Flags().GetString("since")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return usecase.AppLogs(ctx, usecase.AppLogsOptions{
+			Namespace: args[0],
+			Name:      args[1],
+			Version:   version,
+			Follow:    follow,
+			Tail:      tail,
+			Since:     since,
+			Stdout:    os.Stdout,
+			Stderr:    os.Stderr,
+		})
+	},
+}
+
+var appStatusCmd = &cobra.Command{
+	Use:          "status <namespace> <name>",
+	Short:        "show app status",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output != "table" && output != "json" {
+			return fmt.Errorf("invalid output format: %s", output)
 		}
 
-		err := usecase.DeployApp(opt)
+		status, err := usecase.GetAppStatus(args[0], args[1])
 		if err != nil {
 			return err
 		}
 
+		if output == "json" {
+			data, err := json.MarshalIndent(status, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+		} else {
+			printAppStatus(status)
+		}
+
+		if !status.Healthy {
+			return ErrAppNotHealthy
+		}
 		return nil
 	},
 }
-var appLogCmd = &cobra.Command{}
-var appStatusCmd = &cobra.Command{}
+
+func printAppStatus(status *usecase.AppStatus) {
+	fmt.Printf("%-10s %-14s %-10s %-10s %-12s %-8s %-6s %-8s %-15s %-15s\n",
+		"VERSION", "CONTAINER", "STATE", "HEALTH", "UPTIME", "RESTARTS", "CPU", "MEMORY", "TRAEFIK_IP", "NAMESPACE_IP",
+	)
+	for _, v := range status.Versions {
+		fmt.Printf("%-10s %-14s %-10s %-10s %-12s %-8d %-6s %-8s %-15s %-15s\n",
+			v.Version,
+			shortID(v.ContainerId),
+			v.State,
+			orDash(v.Health),
+			orDash(v.Uptime),
+			v.RestartCount,
+			strconv.FormatFloat(v.CPU, 'f', -1, 64),
+			formatMemory(v.Memory),
+			orDash(v.TraefikIp),
+			orDash(v.NamespaceIp),
+		)
+	}
+
+	fmt.Println()
+	fmt.Printf("%-10s %-40s %-50s %-s\n", "VERSION", "ROUTER", "RULE", "SERVERS")
+	for _, v := range status.Versions {
+		for _, r := range v.Routes {
+			fmt.Printf("%-10s %-40s %-50s %-s\n",
+				v.Version,
+				r.Router,
+				r.Rule,
+				strings.Join(r.Servers, ","),
+			)
+		}
+	}
+}
+
+func formatMemory(bytes int64) string {
+	if bytes <= 0 {
+		return "-"
+	}
+	const mb = 1024 * 1024
+	if bytes%(1024*mb) == 0 {
+		return fmt.Sprintf("%dG", bytes/(1024*mb))
+	}
+	return fmt.Sprintf("%dM", bytes/mb)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+var appRollbackCmd = &cobra.Command{
+	Use:   "rollback <namespace> <name>",
+	Short: "rollback app to a previous version",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, _ := cmd.Flags().GetString("to")
+
+		from, to, err := usecase.RollbackApp(usecase.RollbackAppOptions{
+			Namespace: args[0],
+			Name:      args[1],
+			To:        to,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("app [%s] rolled back from [%s] to [%s]\n", args[1], orDash(from), to)
+		return nil
+	},
+}
+
+var appHistoryCmd = &cobra.Command{
+	Use:   "history <namespace> <name>",
+	Short: "list app deployment history",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output != "table" && output != "json" {
+			return fmt.Errorf("invalid output format: %s", output)
+		}
+
+		history, err := usecase.AppHistory(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		if output == "json" {
+			data, err := json.MarshalIndent(history, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		printAppHistory(history)
+		return nil
+	},
+}
+
+func printAppHistory(history []domain.Deployment) {
+	fmt.Printf("%-10s %-9s %-8s %-22s %-10s %-9s %-20s %-10s %-10s %-s\n",
+		"ID", "TYPE", "TRIGGER", "REF", "VERSION", "STATUS", "STARTED", "DURATION", "BUILD", "ERROR",
+	)
+	for _, d := range history {
+		build := "-"
+		if d.BuildDuration > 0 {
+			build = d.BuildDuration.Truncate(time.Second).String()
+		}
+		fmt.Printf("%-10s %-9s %-8s %-22s %-10s %-9s %-20s %-10s %-10s %-s\n",
+			d.ID,
+			d.Type,
+			d.Trigger,
+			orDash(d.Ref()),
+			orDash(d.Version),
+			d.Status,
+			d.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			d.Duration().Truncate(time.Second).String(),
+			build,
+			d.Error,
+		)
+	}
+}
+
+var appBuildLogCmd = &cobra.Command{
+	Use:   "build-log <namespace> <name>",
+	Short: "show app build log, follow it while the build is running",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, _ := cmd.Flags().GetString("version")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return usecase.AppBuildLog(ctx, usecase.AppBuildLogOptions{
+			Namespace: args[0],
+			Name:      args[1],
+			Version:   version,
+			Out:       os.Stdout,
+		})
+	},
+}
 
 var appRemoveCmd = &cobra.Command{
 	Use:     "remove <namespace> <name>",
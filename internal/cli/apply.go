@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/usecase"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringP("file", "f", "", "Stack manifest file (yaml or json), - for stdin")
+	applyCmd.Flags().Bool("dry-run", false, "Only print the plan")
+	applyCmd.Flags().Bool("prune", false, "Remove apps, redis and databases not in the manifest")
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply -f <stack.yaml>",
+	Short: "Create or update a namespace from a stack manifest",
+	Long: "Create or update apps, redis and databases of a namespace from a stack manifest.\n" +
+		"Secrets can reference environment variables with ${VAR}.\n" +
+		"Changed redis / database settings are reported as drift and never recreated.",
+	Args:         cobra.ExactArgs(0),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		prune, _ := cmd.Flags().GetBool("prune")
+
+		if file == "" {
+			return errors.New("--file is required")
+		}
+
+		var data []byte
+		var err error
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return err
+		}
+
+		manifest, err := domain.ParseStackManifest(data)
+		if err != nil {
+			return err
+		}
+		if err := manifest.ExpandVars(os.LookupEnv); err != nil {
+			return err
+		}
+
+		plan, err := usecase.PlanStack(manifest, prune)
+		if err != nil {
+			return err
+		}
+		printApplyPlan(plan)
+
+		if dryRun || plan.Empty() {
+			return nil
+		}
+
+		if _, err := usecase.ApplyStack(usecase.ApplyOptions{
+			Manifest: manifest,
+			Prune:    prune,
+		}); err != nil {
+			return err
+		}
+
+		fmt.Printf("namespace '%s' applied\n", plan.Namespace)
+		return nil
+	},
+}
+
+func printApplyPlan(plan *usecase.ApplyPlan) {
+	fmt.Printf("namespace: %s\n", plan.Namespace)
+	if plan.CreateNamespace {
+		fmt.Printf("  + namespace %s\n", plan.Namespace)
+	}
+
+	if len(plan.Items) == 0 && !plan.CreateNamespace {
+		fmt.Println("  no changes")
+		return
+	}
+
+	for _, item := range plan.Items {
+		symbol := map[usecase.PlanAction]string{
+			usecase.PlanCreate: "+",
+			usecase.PlanUpdate: "~",
+			usecase.PlanDelete: "-",
+			usecase.PlanDrift:  "!",
+			usecase.PlanExtra:  "?",
+		}[item.Action]
+
+		fmt.Printf("  %s %-8s %s", symbol, item.Kind, item.Name)
+		switch item.Action {
+		case usecase.PlanDrift:
+			fmt.Print("  (drift, not recreated)")
+		case usecase.PlanExtra:
+			fmt.Print("  (not in manifest, use --prune to remove)")
+		case usecase.PlanUpdate:
+			fmt.Print("  (takes effect on next deploy)")
+		}
+		fmt.Println()
+
+		for _, change := range item.Changes {
+			fmt.Printf("      %s\n", change)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"dockflow/internal/usecase"
+	"fmt"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+	buildCmd.AddCommand(buildCacheCmd)
+	buildCacheCmd.AddCommand(buildCachePruneCmd)
+
+	buildCachePruneCmd.Flags().Duration("older-than", 0, "Only remove cache unused for longer than this, e.g. 168h (default: any age)")
+	buildCachePruneCmd.Flags().Bool("all", false, "Remove all unused cache, not just dangling cache")
+}
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Manage image builds",
+}
+
+var buildCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage build cache",
+}
+
+var buildCachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "remove build cache",
+	Long: "Remove build cache, including the per-app dependency caches (maven, gradle, npm, go, pip, composer)\n" +
+		"that BuildKit keeps across deploys. The next build of each app downloads its dependencies again.",
+	Args:         cobra.ExactArgs(0),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+		all, _ := cmd.Flags().GetBool("all")
+
+		result, err := usecase.PruneBuildCache(olderThan, all)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("removed %d cache records, reclaimed %s\n",
+			result.Removed, units.HumanSize(float64(result.Reclaimed)),
+		)
+		if !result.BuildKit {
+			fmt.Println("docker buildx is not available, builds use the classic builder without dependency caches")
+		}
+		return nil
+	},
+}
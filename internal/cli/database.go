@@ -122,14 +122,15 @@ var databaseRemoveCmd = &cobra.Command{
 }
 
 func printDatabaseList(list []domain.DatabaseSpec) {
-	fmt.Printf("%-12s %-8s %-8s %-10s\n", "NAME", "TYPE", "VERSION", "STATUS")
+	fmt.Printf("%-12s %-10s %-8s\n", "NAME", "TYPE", "VERSION")
 	fmt.Println("----------------------------------------")
 
 	for _, db := range list {
 		fmt.Printf(
-			"%-12s %-8s %-8s %-10s\n",
+			"%-12s %-10s %-8s\n",
 			db.Name,
-			db.DbType, // redis / mysql / pg
+			db.Engine(), // mysql / postgres
+			db.Version(),
 		)
 	}
 }
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/usecase"
+	"dockflow/internal/util"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	appCmd.AddCommand(appEnvCmd)
+	appEnvCmd.AddCommand(appEnvListCmd, appEnvSetCmd, appEnvUnsetCmd, appEnvImportCmd)
+
+	for _, cmd := range []*cobra.Command{appEnvSetCmd, appEnvUnsetCmd, appEnvImportCmd} {
+		cmd.Flags().Bool("no-restart", false, "Only save the change, apply it on the next deploy")
+	}
+	appEnvSetCmd.Flags().Bool("secret", false, "Mark the variables as secret, values are masked in all outputs")
+	appEnvImportCmd.Flags().Bool("secret", false, "Mark the imported variables as secret")
+}
+
+var appEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage app environment variables",
+	Long: "Manage app environment variables.\n" +
+		"Changes are applied by a rolling restart of latest with the current image, unless --no-restart is set.",
+}
+
+/* ---------------- list ---------------- */
+
+var appEnvListCmd = &cobra.Command{
+	Use:     "list <namespace> <name>",
+	Short:   "List app environment variables",
+	Aliases: []string{"ls"},
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envs, err := usecase.ListAppEnv(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%-30s %-7s %-s\n", "KEY", "SECRET", "VALUE")
+		for _, env := range envs {
+			secret := "-"
+			if env.Secret {
+				secret = "yes"
+			}
+			fmt.Printf("%-30s %-7s %-s\n", env.Key, secret, env.Value)
+		}
+		return nil
+	},
+}
+
+/* ---------------- set ---------------- */
+
+var appEnvSetCmd = &cobra.Command{
+	Use:          "set <namespace> <name> KEY=VALUE...",
+	Short:        "Add or change app environment variables",
+	Args:         cobra.MinimumNArgs(3),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		secret, _ := cmd.Flags().GetBool("secret")
+
+		envs, err := parseEnvFlags(args[2:])
+		if err != nil {
+			return err
+		}
+		for i := range envs {
+			if !util.ValidEnvKey(envs[i].Key) {
+				return fmt.Errorf("invalid env key: %s", envs[i].Key)
+			}
+			envs[i].Secret = secret
+		}
+
+		return updateAppEnv(cmd, usecase.UpdateAppOptions{
+			Namespace: args[0],
+			Name:      args[1],
+			SetEnvs:   envs,
+		})
+	},
+}
+
+/* ---------------- unset ---------------- */
+
+var appEnvUnsetCmd = &cobra.Command{
+	Use:          "unset <namespace> <name> KEY...",
+	Short:        "Remove app environment variables",
+	Args:         cobra.MinimumNArgs(3),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateAppEnv(cmd, usecase.UpdateAppOptions{
+			Namespace: args[0],
+			Name:      args[1],
+			UnsetEnvs: args[2:],
+		})
+	},
+}
+
+/* ---------------- import ---------------- */
+
+var appEnvImportCmd = &cobra.Command{
+	Use:          "import <namespace> <name> <file>",
+	Short:        "Import app environment variables from a .env file, - for stdin",
+	Args:         cobra.ExactArgs(3),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		secret, _ := cmd.Flags().GetBool("secret")
+
+		var r io.Reader = os.Stdin
+		if args[2] != "-" {
+			f, err := os.Open(args[2])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+
+		envs, err := util.ParseDotEnv(r)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", args[2], err)
+		}
+		if len(envs) == 0 {
+			return fmt.Errorf("no env found in %s", args[2])
+		}
+		for i := range envs {
+			envs[i].Secret = secret
+		}
+
+		return updateAppEnv(cmd, usecase.UpdateAppOptions{
+			Namespace: args[0],
+			Name:      args[1],
+			SetEnvs:   envs,
+		})
+	},
+}
+
+/* ================= helpers ================= */
+
+// updateAppEnv 保存 env 修改，默认滚动重启 latest 使修改生效
+func updateAppEnv(cmd *cobra.Command, opt usecase.UpdateAppOptions) error {
+	noRestart, _ := cmd.Flags().GetBool("no-restart")
+	opt.Restart = !noRestart
+
+	result, err := usecase.UpdateApp(opt)
+	if err != nil {
+		return err
+	}
+
+	printEnvChanges(opt.SetEnvs, opt.UnsetEnvs)
+	switch {
+	case result.Restarted:
+		fmt.Printf("app [%s] latest restarted with version [%s]\n", opt.Name, result.Version)
+	case result.App.NeedsRedeploy:
+		fmt.Printf("app [%s] env saved, applied on the next deploy or restart\n", opt.Name)
+	}
+	return nil
+}
+
+// printEnvChanges 只输出 key
+func printEnvChanges(set []domain.Env, unset []string) {
+	for _, env := range set {
+		fmt.Printf("set   %s\n", env.Key)
+	}
+	for _, key := range unset {
+		fmt.Printf("unset %s\n", key)
+	}
+}
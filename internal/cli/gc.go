@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"dockflow/internal/usecase"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+
+	gcCmd.Flags().Bool("dry-run", false, "Only show what would be removed")
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove app versions beyond the retention policy",
+	Long: "Remove containers, images and deploy entries of old app versions.\n" +
+		"Each app keeps its last N versions (app --keep-versions, default daemon keep_versions),\n" +
+		"the running version and its rollback target. Images built by dockflow without a deploy entry are removed too.",
+	Args:         cobra.ExactArgs(0),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		report, err := usecase.GC(usecase.GCOptions{DryRun: dryRun})
+		if err != nil {
+			return err
+		}
+
+		if len(report.Items) == 0 {
+			fmt.Println("nothing to remove")
+			return nil
+		}
+
+		fmt.Printf("%-10s %-30s %-12s %-40s %-8s %-s\n",
+			"KIND", "APP", "VERSION", "REF", "REMOVED", "DETAIL",
+		)
+		for _, item := range report.Items {
+			removed := "no"
+			if item.Removed {
+				removed = "yes"
+			}
+			fmt.Printf("%-10s %-30s %-12s %-40s %-8s %-s\n",
+				item.Kind,
+				item.Namespace+"/"+item.App,
+				orDash(item.Version),
+				orDash(item.Ref),
+				removed,
+				item.Detail,
+			)
+		}
+		return nil
+	},
+}
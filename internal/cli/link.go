@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/usecase"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	appCmd.AddCommand(appLinkCmd, appUnlinkCmd)
+
+	for _, cmd := range []*cobra.Command{appLinkCmd, appUnlinkCmd} {
+		cmd.Flags().String("redis", "", "Redis name in the namespace")
+		cmd.Flags().String("database", "", "Database name in the namespace")
+		cmd.Flags().Bool("no-restart", false, "Only save the link, apply it on the next deploy")
+	}
+	appLinkCmd.Flags().String("prefix", "", "Env prefix, e.g. CACHE injects CACHE_REDIS_URL")
+}
+
+var appLinkCmd = &cobra.Command{
+	Use:   "link <namespace> <name> --redis <name> | --database <name>",
+	Short: "Inject redis / database connection env into the app",
+	Long: "Link a redis or database of the same namespace to the app. At run time the app gets\n" +
+		"  redis:    REDIS_HOST, REDIS_PORT, REDIS_PASSWORD, REDIS_URL\n" +
+		"  database: DB_HOST, DB_PORT, DB_NAME, DB_USER, DB_PASSWORD, DATABASE_URL\n" +
+		"Env set on the app with the same key takes precedence.",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		link, err := parseLinkFlags(cmd)
+		if err != nil {
+			return err
+		}
+		link.Prefix, _ = cmd.Flags().GetString("prefix")
+
+		noRestart, _ := cmd.Flags().GetBool("no-restart")
+		result, err := usecase.LinkApp(usecase.LinkAppOptions{
+			Namespace: args[0],
+			Name:      args[1],
+			Link:      link,
+			Restart:   !noRestart,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("app [%s] linked to %s\n", args[1], link)
+		printLinkResult(args[1], result)
+		return nil
+	},
+}
+
+var appUnlinkCmd = &cobra.Command{
+	Use:          "unlink <namespace> <name> --redis <name> | --database <name>",
+	Short:        "Remove a redis / database link from the app",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		link, err := parseLinkFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		noRestart, _ := cmd.Flags().GetBool("no-restart")
+		result, err := usecase.UnlinkApp(usecase.LinkAppOptions{
+			Namespace: args[0],
+			Name:      args[1],
+			Link:      link,
+			Restart:   !noRestart,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("app [%s] unlinked from %s/%s\n", args[1], link.Kind, link.Name)
+		printLinkResult(args[1], result)
+		return nil
+	},
+}
+
+// parseLinkFlags --redis 与 --database 二选一
+func parseLinkFlags(cmd *cobra.Command) (domain.AppLink, error) {
+	redis, _ := cmd.Flags().GetString("redis")
+	database, _ := cmd.Flags().GetString("database")
+
+	switch {
+	case redis != "" && database != "":
+		return domain.AppLink{}, errors.New("use either --redis or --database")
+	case redis != "":
+		return domain.AppLink{Kind: domain.ResourceRedis, Name: redis}, nil
+	case database != "":
+		return domain.AppLink{Kind: domain.ResourceDatabase, Name: database}, nil
+	default:
+		return domain.AppLink{}, errors.New("--redis or --database is required")
+	}
+}
+
+func printLinkResult(name string, result *usecase.UpdateAppResult) {
+	switch {
+	case result.Restarted:
+		fmt.Printf("app [%s] latest restarted with version [%s]\n", name, result.Version)
+	case result.App.NeedsRedeploy:
+		fmt.Printf("app [%s] applied on the next deploy or restart\n", name)
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
@@ -12,7 +13,10 @@ import (
 
 func init() {
 	rootCmd.AddCommand(nsCmd)
-	nsCmd.AddCommand(nsCreateCmd, nsListCmd, nsRemoveCmd, nsInspectCmd)
+	nsCmd.AddCommand(nsCreateCmd, nsListCmd, nsRemoveCmd, nsInspectCmd, nsExportCmd)
+
+	nsExportCmd.Flags().StringP("output", "o", "yaml", "Output format: yaml | json")
+	nsExportCmd.Flags().StringP("file", "f", "", "Write the manifest to file instead of stdout")
 }
 
 var nsCmd = &cobra.Command{
@@ -86,6 +90,56 @@ var nsInspectCmd = &cobra.Command{
 	},
 }
 
+/* ---------------- export ---------------- */
+
+var nsExportCmd = &cobra.Command{
+	Use:   "export <name>",
+	Short: "Export namespace as a stack manifest",
+	Long: "Export apps, redis and databases of a namespace as a manifest for `dockflow apply`.\n" +
+		"Container ids, ips and networks are left out; tokens, passwords and secret-like env\n" +
+		"values are exported as ${VAR} references.",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		file, _ := cmd.Flags().GetString("file")
+
+		manifest, vars, err := usecase.ExportNamespace(args[0])
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		switch output {
+		case "yaml", "yml":
+			data, err = manifest.YAML()
+		case "json":
+			data, err = json.MarshalIndent(manifest, "", "  ")
+			data = append(data, '\n')
+		default:
+			return fmt.Errorf("unsupported output format: %s", output)
+		}
+		if err != nil {
+			return err
+		}
+
+		if file == "" {
+			fmt.Print(string(data))
+		} else if err := os.WriteFile(file, data, 0600); err != nil {
+			return err
+		}
+
+		// 变量提示输出到 stderr，不影响重定向的 manifest
+		if len(vars) > 0 {
+			fmt.Fprintln(os.Stderr, "# set these variables before apply:")
+			for _, name := range vars {
+				fmt.Fprintf(os.Stderr, "#   %s\n", name)
+			}
+		}
+		return nil
+	},
+}
+
 /* ================= helpers ================= */
 
 func printError(err error) {
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"dockflow/internal/service/queue"
+	"dockflow/internal/usecase"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(queueCmd)
+	queueCmd.AddCommand(queueListCmd)
+}
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Show daemon deploy queue",
+}
+
+var queueListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "list running and queued deploys",
+	Aliases: []string{"ls"},
+	Args:    cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobs, err := usecase.ListDeployQueue()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%-30s %-8s %-8s %-24s %-10s %-10s %-s\n",
+			"APP", "STATE", "TRIGGER", "REF", "COMMIT", "WAITING", "COLLAPSED",
+		)
+		for _, job := range jobs {
+			ref := "-"
+			if job.Type == queue.JobRollback {
+				ref = "rollback:" + lo.CoalesceOrEmpty(job.RollbackTo, "previous")
+			} else if job.Tag != "" {
+				ref = "tag:" + job.Tag
+			} else if job.Branch != "" {
+				ref = "branch:" + job.Branch
+			}
+
+			waiting := time.Since(job.EnqueuedAt)
+			if job.StartedAt != nil {
+				waiting = job.StartedAt.Sub(job.EnqueuedAt)
+			}
+
+			fmt.Printf("%-30s %-8s %-8s %-24s %-10s %-10s %-d\n",
+				job.Namespace+"/"+job.App,
+				job.State,
+				job.Trigger,
+				ref,
+				shortCommit(job.Commit),
+				waiting.Truncate(time.Second).String(),
+				job.Collapsed,
+			)
+		}
+		return nil
+	},
+}
+
+func shortCommit(commit string) string {
+	if commit == "" {
+		return "-"
+	}
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
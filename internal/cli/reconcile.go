@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"dockflow/internal/usecase"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+
+	reconcileCmd.Flags().Bool("dry-run", false, "Only report differences, do not change anything")
+}
+
+var reconcileCmd = &cobra.Command{
+	Use:          "reconcile",
+	Short:        "Converge docker containers to the namespace state",
+	Args:         cobra.ExactArgs(0),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		report, err := usecase.Reconcile(usecase.ReconcileOptions{DryRun: dryRun})
+		if err != nil {
+			return err
+		}
+
+		if len(report.Events) == 0 {
+			fmt.Println("everything is in sync")
+			return nil
+		}
+
+		fmt.Printf("%-12s %-9s %-30s %-10s %-8s %-s\n",
+			"ACTION", "KIND", "NAME", "VERSION", "APPLIED", "DETAIL",
+		)
+		for _, event := range report.Events {
+			applied := "no"
+			if event.Applied {
+				applied = "yes"
+			}
+			fmt.Printf("%-12s %-9s %-30s %-10s %-8s %-s\n",
+				event.Action,
+				orDash(string(event.Kind)),
+				event.Namespace+"/"+orDash(event.Name),
+				orDash(event.Version),
+				applied,
+				event.Detail,
+			)
+		}
+		return nil
+	},
+}
@@ -80,14 +80,14 @@ var redisRemoveCmd = &cobra.Command{
 }
 
 func printRedisList(list []domain.RedisSpec) {
-	fmt.Printf("%-12s %-8s %-8s %-10s\n", "NAME", "TYPE", "VERSION", "STATUS")
+	fmt.Printf("%-12s %-8s\n", "NAME", "VERSION")
 	fmt.Println("----------------------------------------")
 
 	for _, db := range list {
 		fmt.Printf(
-			"%-12s %-8s %-8s %-10s\n",
+			"%-12s %-8s\n",
 			db.Name,
-			db.Version, // redis / mysql / pg
+			db.Version,
 		)
 	}
 }
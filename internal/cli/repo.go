@@ -2,6 +2,7 @@ package cli
 
 import (
 	"dockflow/internal/usecase"
+	"dockflow/internal/util"
 	"errors"
 	"fmt"
 
@@ -155,16 +156,16 @@ var repoListCmd = &cobra.Command{
 		}
 		for _, v := range git.Gitee {
 			print(v.Name)
-			print(v.Token)
+			print(util.MaskSecret(v.Token))
 		}
 		for _, v := range git.Github {
 			print(v.Name)
-			print(v.Token)
+			print(util.MaskSecret(v.Token))
 		}
 		for _, v := range git.Gitlab {
 			print(v.Url)
 			print(v.Name)
-			print(v.Token)
+			print(util.MaskSecret(v.Token))
 		}
 		return nil
 	},
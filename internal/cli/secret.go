@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"dockflow/internal/service/filesystem"
+	"dockflow/internal/usecase"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(secretCmd)
+	secretCmd.AddCommand(secretSetCmd, secretListCmd, secretRemoveCmd, secretMigrateCmd)
+
+	secretRemoveCmd.Flags().Bool("force", false, "Remove the secret even if it is referenced")
+}
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage encrypted secrets",
+	Long: fmt.Sprintf(
+		"Secrets are encrypted with the host key %s and referenced as secret://<name>\n"+
+			"in tokens, passwords and app env. Values are only decrypted when they are used.",
+		filesystem.SecretKeyFile,
+	),
+}
+
+/* ---------------- set ---------------- */
+
+var secretSetCmd = &cobra.Command{
+	Use:          "set <name> [value]",
+	Short:        "Create or update a secret, read the value from stdin when omitted",
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var value string
+		if len(args) == 2 && args[1] != "-" {
+			value = args[1]
+		} else {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+
+		if err := usecase.SetSecret(args[0], value); err != nil {
+			return err
+		}
+		fmt.Printf("secret [%s] saved, reference it as secret://%s\n", args[0], args[0])
+		return nil
+	},
+}
+
+/* ---------------- list ---------------- */
+
+var secretListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List secrets, values are never printed",
+	Aliases: []string{"ls"},
+	Args:    cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, err := usecase.ListSecrets()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%-40s %-20s %-s\n", "NAME", "UPDATED", "USED BY")
+		for _, s := range list {
+			fmt.Printf("%-40s %-20s %-s\n",
+				s.Name,
+				s.UpdatedAt.Local().Format("2006-01-02 15:04:05"),
+				orDash(strings.Join(s.UsedBy, ", ")),
+			)
+		}
+		return nil
+	},
+}
+
+/* ---------------- remove ---------------- */
+
+var secretRemoveCmd = &cobra.Command{
+	Use:          "remove <name>",
+	Short:        "Remove secret",
+	Aliases:      []string{"rm"},
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+
+		if err := usecase.RemoveSecret(args[0], force); err != nil {
+			return err
+		}
+		fmt.Printf("secret [%s] removed\n", args[0])
+		return nil
+	},
+}
+
+/* ---------------- migrate ---------------- */
+
+var secretMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Encrypt plain text tokens, passwords and secret env into secrets",
+	Long: "Encrypt git tokens, app tokens, webhook secrets, secret env, redis and database passwords\n" +
+		"that are still stored in plain text, and replace them with secret:// references.",
+	Args:         cobra.ExactArgs(0),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrated, err := usecase.MigrateSecrets()
+		for _, owner := range migrated {
+			fmt.Println("encrypted", owner)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%d values migrated\n", len(migrated))
+		return nil
+	},
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"dockflow/internal/service/filesystem"
+	"dockflow/internal/state"
+	"dockflow/internal/usecase"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+	stateCmd.AddCommand(stateMigrateCmd, stateInfoCmd)
+}
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage dockflow state store",
+}
+
+var stateInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "show current state backend",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println("backend:", usecase.StateBackend())
+		return nil
+	},
+}
+
+var stateMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "move config and namespace files into the embedded database",
+	Long: fmt.Sprintf(
+		"Copy %s and every namespace.json into %s.\n"+
+			"The original files are kept as a backup. Stop the daemon before migrating.",
+		filesystem.CfgPath, filesystem.StateDBFile,
+	),
+	Args:         cobra.ExactArgs(0),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		result, err := usecase.MigrateState()
+		if err != nil {
+			return err
+		}
+
+		for _, kind := range state.Kinds {
+			fmt.Printf("%-10s %d\n", kind, result[kind])
+		}
+		fmt.Println("state migrated to", filesystem.StateDBFile)
+		return nil
+	},
+}
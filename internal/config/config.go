@@ -1,9 +1,10 @@
 package config
 
 import (
-	"dockflow/internal/service/filesystem"
+	"dockflow/internal/service/secret"
+	"dockflow/internal/state"
 	"errors"
-	"os"
+	"time"
 
 	"github.com/samber/lo"
 	"gopkg.in/yaml.v3"
@@ -14,6 +15,44 @@ type Config struct {
 	Platform   Platform `yaml:"platform"`
 	Git        Git      `yaml:"git"`
 	WebHookUrl string   `yaml:"webhook_url"`
+	Daemon     Daemon   `yaml:"daemon"`
+}
+
+type Daemon struct {
+	// 同时执行的部署数量，<= 0 时使用默认值
+	DeployConcurrency int `yaml:"deploy_concurrency"`
+	// 管理 API 的 TCP 监听地址，如 127.0.0.1:8091，为空时只监听 unix socket
+	APIListen string `yaml:"api_listen"`
+	// TCP 监听的 bearer token，api_listen 非空时必填，支持 secret://<name>
+	APIToken string `yaml:"api_token"`
+	// reconcile 间隔，如 1m，为空时使用默认值，0 关闭
+	ReconcileInterval string `yaml:"reconcile_interval"`
+	// gc 间隔，如 1h，为空时使用默认值，0 关闭
+	GCInterval string `yaml:"gc_interval"`
+	// 应用未配置 retention 时保留的版本数，<= 0 时使用默认值
+	KeepVersions int `yaml:"keep_versions"`
+}
+
+// DefaultReconcileInterval 未配置 reconcile_interval 时的间隔
+const DefaultReconcileInterval = time.Minute
+
+// ReconcileEvery reconcile 间隔，<= 0 表示关闭
+func (d Daemon) ReconcileEvery() (time.Duration, error) {
+	if d.ReconcileInterval == "" {
+		return DefaultReconcileInterval, nil
+	}
+	return time.ParseDuration(d.ReconcileInterval)
+}
+
+// DefaultGCInterval 未配置 gc_interval 时的间隔
+const DefaultGCInterval = time.Hour
+
+// GCEvery gc 间隔，<= 0 表示关闭
+func (d Daemon) GCEvery() (time.Duration, error) {
+	if d.GCInterval == "" {
+		return DefaultGCInterval, nil
+	}
+	return time.ParseDuration(d.GCInterval)
 }
 
 type Platform struct {
@@ -70,7 +109,7 @@ type Git struct {
 // }
 
 func Load() (*Config, error) {
-	data, err := os.ReadFile(filesystem.CfgPath)
+	data, err := state.Default().Get(state.KindConfig, state.ConfigKey)
 	if err != nil {
 		return nil, err
 	}
@@ -93,9 +132,10 @@ func Save(cfg *Config) error {
 		return err
 	}
 
-	return os.WriteFile(filesystem.CfgPath, data, 0644)
+	return state.Default().Put(state.KindConfig, state.ConfigKey, data)
 }
 
+// FindGit 返回解密后的 token，未配置时返回空字符串
 func FindGit(host string, username string) (string, error) {
 	cfg, err := Load()
 	if err != nil {
@@ -107,21 +147,21 @@ func FindGit(host string, username string) (string, error) {
 			return gitee.Name == username
 		})
 		if found {
-			return gitee.Token, nil
+			return secret.Resolve(gitee.Token)
 		}
 	case "github.com":
 		github, found := lo.Find(cfg.Git.Github, func(github GitToken) bool {
 			return github.Name == username
 		})
 		if found {
-			return github.Token, nil
+			return secret.Resolve(github.Token)
 		}
 	default:
 		gitlab, found := lo.Find(cfg.Git.Gitlab, func(gitlab GitGitlab) bool {
 			return gitlab.Name == username && gitlab.Url == host
 		})
 		if found {
-			return gitlab.Token, nil
+			return secret.Resolve(gitlab.Token)
 		}
 	}
 	return "", nil
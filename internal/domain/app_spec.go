@@ -2,6 +2,9 @@ package domain
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 )
 
 type AppURL struct {
@@ -9,20 +12,36 @@ type AppURL struct {
 	Port string `json:"port"` // container port
 }
 
-type Env struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
-}
-
 type Trigger struct {
 	Type string `json:"type"` // branch | tag
 	Rule string `json:"rule"` // main | v* | v1.*
 }
 
+// HealthCheck 应用健康检查，转换为 docker healthcheck
+type HealthCheck struct {
+	Path        string `json:"path"`        // HTTP path, e.g. /health
+	Port        string `json:"port"`        // container port, default first url port
+	Interval    string `json:"interval"`    // e.g. 10s
+	Timeout     string `json:"timeout"`     // e.g. 3s
+	Retries     int    `json:"retries"`     // unhealthy after N failures
+	StartPeriod string `json:"startPeriod"` // e.g. 30s
+}
+
 type AppDeploy struct {
-	ContainerId string `json:"containerId"`
-	Version     string `json:"version"`
-	Url         string `json:"url"`
+	ContainerId  string    `json:"containerId"`
+	Version      string    `json:"version"`
+	Url          string    `json:"url"`
+	Image        string    `json:"image,omitempty"`        // <app>:<version>
+	DeployedAt   time.Time `json:"deployedAt"`             // 容器就绪、记录写入的时间
+	RollbackFrom string    `json:"rollbackFrom,omitempty"` // latest 由回滚产生时，被替换的版本
+}
+
+// ImageVersion 镜像 tag 对应的版本，latest 记录用来判断当前运行的版本
+func (d AppDeploy) ImageVersion() string {
+	if idx := strings.LastIndex(d.Image, ":"); idx != -1 {
+		return d.Image[idx+1:]
+	}
+	return ""
 }
 
 type AppSpec struct {
@@ -37,27 +56,118 @@ type AppSpec struct {
 	URLs      []AppURL           `json:"url"`     // Access rules
 	Deploy    []AppDeploy        `json:"deploy"`
 	BuildArg  map[string]*string `json:"buildArg"`
+	Platform  string             `json:"platform,omitempty"` // Build platform, e.g. linux/arm64 (optional)
 	Secret    string             `json:"secret"`
+	Health    *HealthCheck       `json:"health,omitempty"`    // Health check (optional)
+	Links     []AppLink          `json:"links,omitempty"`     // Linked redis / database
+	History   []Deployment       `json:"history,omitempty"`   // Deploy attempts, newest last
+	Retention *Retention         `json:"retention,omitempty"` // Version retention (optional)
+	// NeedsRedeploy 配置已修改但 latest 仍以旧配置运行，下次部署 / 重启 latest 后清除
+	NeedsRedeploy bool `json:"needsRedeploy,omitempty"`
 }
 
-func SaveApp(app AppSpec) error {
-	ns, err := NewNamespace(app.Namespace)
-	if err != nil {
-		return err
+// Latest 当前 latest 的部署记录
+func (a AppSpec) Latest() (AppDeploy, bool) {
+	for _, deploy := range a.Deploy {
+		if deploy.Version == "latest" {
+			return deploy, true
+		}
 	}
-	if ns == nil {
-		return fmt.Errorf("namespace [%s] not exist", app.Namespace)
+	return AppDeploy{}, false
+}
+
+// MarkNeedsRedeploy 运行中的 latest 与当前配置不一致，未部署过的应用不需要标记
+func (a *AppSpec) MarkNeedsRedeploy() {
+	if _, ok := a.Latest(); ok {
+		a.NeedsRedeploy = true
 	}
-	var appIndex = -1
-	for index, _app := range ns.App {
-		if app.Name == _app.Name {
-			appIndex = index
+}
+
+// UpdateApp 在 namespace 事务中修改磁盘上最新的 app 并返回修改后的结果
+// fn 只应修改调用方负责的字段，期间其他命令对同一 app 的修改不会被覆盖
+func UpdateApp(namespace, name string, fn func(app *AppSpec) error) (AppSpec, error) {
+	var updated AppSpec
+	err := UpdateNamespace(namespace, func(ns *Namespace) error {
+		for i := range ns.App {
+			if ns.App[i].Name != name {
+				continue
+			}
+			if err := fn(&ns.App[i]); err != nil {
+				return err
+			}
+			updated = ns.App[i]
+			return nil
 		}
+		return fmt.Errorf("app [%s] not exist", name)
+	})
+	return updated, err
+}
+
+const (
+	DefaultHealthInterval = 10 * time.Second
+	DefaultHealthTimeout  = 3 * time.Second
+	DefaultHealthRetries  = 3
+)
+
+// healthPathPattern 健康检查路径只允许 URL path 与 query 中的常用字符，路径会写入 CMD-SHELL
+var healthPathPattern = regexp.MustCompile(`^/[A-Za-z0-9._~%/:@?=&+-]*$`)
+
+var healthPortPattern = regexp.MustCompile(`^[0-9]+$`)
+
+func (h HealthCheck) Validate() error {
+	if !healthPathPattern.MatchString(h.Path) {
+		return fmt.Errorf("invalid health path: %s (must start with / and contain only URL path characters)", h.Path)
 	}
-	if appIndex == -1 {
-		return fmt.Errorf("app [%s] not exist", app.Name)
+	if h.Port != "" && !healthPortPattern.MatchString(h.Port) {
+		return fmt.Errorf("invalid health port: %s", h.Port)
+	}
+	for _, value := range []string{h.Interval, h.Timeout, h.StartPeriod} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid health duration: %s", value)
+		}
+	}
+	if h.Retries < 0 {
+		return fmt.Errorf("invalid health retries: %d", h.Retries)
 	}
-	ns.App[appIndex] = app
-	ns.Save()
 	return nil
 }
+
+// HealthPort 健康检查端口，未设置时使用第一个 url 的端口，都没有时为空
+func (a AppSpec) HealthPort() string {
+	if a.Health == nil {
+		return ""
+	}
+	if a.Health.Port != "" {
+		return a.Health.Port
+	}
+	if len(a.URLs) > 0 {
+		return a.URLs[0].Port
+	}
+	return ""
+}
+
+// Durations 返回 interval / timeout / start period，未设置时使用默认值
+func (h HealthCheck) Durations() (interval, timeout, startPeriod time.Duration) {
+	interval = parseDurationOr(h.Interval, DefaultHealthInterval)
+	timeout = parseDurationOr(h.Timeout, DefaultHealthTimeout)
+	startPeriod = parseDurationOr(h.StartPeriod, 0)
+	return
+}
+
+func (h HealthCheck) RetriesOrDefault() int {
+	if h.Retries <= 0 {
+		return DefaultHealthRetries
+	}
+	return h.Retries
+}
+
+func parseDurationOr(value string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return def
+	}
+	return d
+}
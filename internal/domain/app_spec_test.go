@@ -0,0 +1,53 @@
+package domain
+
+import "testing"
+
+func TestHealthCheckValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		health  HealthCheck
+		wantErr bool
+	}{
+		{name: "path only", health: HealthCheck{Path: "/health"}},
+		{name: "path with query", health: HealthCheck{Path: "/health?full=1&db=on", Port: "8080"}},
+		{name: "empty path", health: HealthCheck{}, wantErr: true},
+		{name: "relative path", health: HealthCheck{Path: "health"}, wantErr: true},
+		{name: "space", health: HealthCheck{Path: "/a b"}, wantErr: true},
+		{name: "command separator", health: HealthCheck{Path: "/;rm -rf /"}, wantErr: true},
+		{name: "variable expansion", health: HealthCheck{Path: "/$HOME"}, wantErr: true},
+		{name: "quote", health: HealthCheck{Path: "/'x'"}, wantErr: true},
+		{name: "invalid port", health: HealthCheck{Path: "/", Port: "80;true"}, wantErr: true},
+		{name: "invalid duration", health: HealthCheck{Path: "/", Interval: "ten"}, wantErr: true},
+		{name: "negative retries", health: HealthCheck{Path: "/", Retries: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.health.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHealthPort(t *testing.T) {
+	tests := []struct {
+		name string
+		app  AppSpec
+		want string
+	}{
+		{name: "no health check", app: AppSpec{URLs: []AppURL{{Host: "a.com", Port: "80"}}}, want: ""},
+		{name: "explicit port", app: AppSpec{Health: &HealthCheck{Port: "9000"}, URLs: []AppURL{{Port: "80"}}}, want: "9000"},
+		{name: "first url port", app: AppSpec{Health: &HealthCheck{}, URLs: []AppURL{{Port: "80"}, {Port: "81"}}}, want: "80"},
+		{name: "no port and no url", app: AppSpec{Health: &HealthCheck{}}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.app.HealthPort(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
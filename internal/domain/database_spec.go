@@ -1,5 +1,7 @@
 package domain
 
+import "strings"
+
 type DatabaseSpec struct {
 	Namespace   string
 	Name        string
@@ -38,3 +40,39 @@ type DatabaseSpec struct {
 // 		Eviction:  eviction,
 // 	}
 // }
+
+// Engine 由镜像名得到数据库类型，如 library/mysql:5.7 → mysql
+func (d DatabaseSpec) Engine() string {
+	name := strings.ToLower(d.DbType)
+
+	if idx := strings.LastIndex(name, "/"); idx != -1 {
+		name = name[idx+1:]
+	}
+	if idx := strings.Index(name, ":"); idx != -1 {
+		name = name[:idx]
+	}
+	if name == "postgresql" {
+		name = "postgres"
+	}
+	return name
+}
+
+// Version 镜像 tag，如 mysql:5.7 → 5.7，未指定 tag 时为 latest
+func (d DatabaseSpec) Version() string {
+	name := d.DbType
+	if idx := strings.LastIndex(name, "/"); idx != -1 {
+		name = name[idx+1:]
+	}
+	if idx := strings.Index(name, ":"); idx != -1 {
+		return name[idx+1:]
+	}
+	return "latest"
+}
+
+// Port 容器内监听端口
+func (d DatabaseSpec) Port() string {
+	if d.Engine() == "postgres" {
+		return "5432"
+	}
+	return "3306"
+}
@@ -1 +1,144 @@
 package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+// MaxDeploymentHistory 每个应用保留的部署记录条数
+const MaxDeploymentHistory = 50
+
+type DeployTrigger string
+
+const (
+	DeployTriggerCLI    DeployTrigger = "cli"
+	DeployTriggerGitHub DeployTrigger = "github"
+	DeployTriggerGitLab DeployTrigger = "gitlab"
+	DeployTriggerGitee  DeployTrigger = "gitee"
+	DeployTriggerAPI    DeployTrigger = "api"
+)
+
+type DeploymentType string
+
+const (
+	DeploymentTypeDeploy   DeploymentType = "deploy"
+	DeploymentTypeRollback DeploymentType = "rollback"
+	DeploymentTypeRestart  DeploymentType = "restart"
+)
+
+type DeploymentStatus string
+
+const (
+	DeploymentRunning DeploymentStatus = "running"
+	DeploymentSuccess DeploymentStatus = "success"
+	DeploymentFailed  DeploymentStatus = "failed"
+)
+
+// ErrorInterrupted 进程退出（崩溃、重启）时未结束的部署记录的错误信息
+const ErrorInterrupted = "interrupted"
+
+// Deployment 一次部署（或回滚）尝试的记录
+type Deployment struct {
+	ID            string           `json:"id"`
+	Type          DeploymentType   `json:"type"`
+	Trigger       DeployTrigger    `json:"trigger"`
+	Branch        string           `json:"branch,omitempty"`
+	Tag           string           `json:"tag,omitempty"`
+	Commit        string           `json:"commit,omitempty"`
+	Version       string           `json:"version,omitempty"`
+	RollbackFrom  string           `json:"rollbackFrom,omitempty"`
+	Status        DeploymentStatus `json:"status"`
+	StartedAt     time.Time        `json:"startedAt"`
+	FinishedAt    *time.Time       `json:"finishedAt,omitempty"`
+	BuildDuration time.Duration    `json:"buildDuration,omitempty"`
+	BuildLog      string           `json:"buildLog,omitempty"`
+	Error         string           `json:"error,omitempty"`
+}
+
+func NewDeployment(typ DeploymentType, trigger DeployTrigger) Deployment {
+	now := time.Now()
+	if trigger == "" {
+		trigger = DeployTriggerCLI
+	}
+	return Deployment{
+		ID:        strconv.FormatInt(now.UnixMilli(), 36),
+		Type:      typ,
+		Trigger:   trigger,
+		Status:    DeploymentRunning,
+		StartedAt: now,
+	}
+}
+
+// Finish 根据 err 标记部署结果
+func (d *Deployment) Finish(err error) {
+	now := time.Now()
+	d.FinishedAt = &now
+	if err != nil {
+		d.Status = DeploymentFailed
+		d.Error = err.Error()
+		return
+	}
+	d.Status = DeploymentSuccess
+	d.Error = ""
+}
+
+// Ref 分支或 tag
+func (d Deployment) Ref() string {
+	if d.Tag != "" {
+		return "tag:" + d.Tag
+	}
+	if d.Branch != "" {
+		return "branch:" + d.Branch
+	}
+	return ""
+}
+
+// Duration 部署耗时，未结束时为到当前的耗时
+func (d Deployment) Duration() time.Duration {
+	if d.FinishedAt == nil {
+		return time.Since(d.StartedAt)
+	}
+	return d.FinishedAt.Sub(d.StartedAt)
+}
+
+// RecordDeployment 按 ID 新增或更新部署记录，只保留最近 MaxDeploymentHistory 条
+func (a *AppSpec) RecordDeployment(record Deployment) {
+	for i := range a.History {
+		if a.History[i].ID == record.ID {
+			a.History[i] = record
+			return
+		}
+	}
+
+	a.History = append(a.History, record)
+	if len(a.History) > MaxDeploymentHistory {
+		a.History = a.History[len(a.History)-MaxDeploymentHistory:]
+	}
+}
+
+// HasRunningDeployment 是否有未结束的部署记录
+// 部署进程异常退出时记录会停留在 running，需要持有应用锁时用 InterruptRunningDeployments 清理
+func (a AppSpec) HasRunningDeployment() bool {
+	for _, record := range a.History {
+		if record.Status == DeploymentRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// InterruptRunningDeployments 把未结束的部署记录标记为失败，返回标记的条数
+func (a *AppSpec) InterruptRunningDeployments() int {
+	count := 0
+	now := time.Now()
+	for i := range a.History {
+		if a.History[i].Status != DeploymentRunning {
+			continue
+		}
+		a.History[i].Status = DeploymentFailed
+		a.History[i].Error = ErrorInterrupted
+		a.History[i].FinishedAt = &now
+		count++
+	}
+	return count
+}
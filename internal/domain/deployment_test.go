@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInterruptRunningDeployments(t *testing.T) {
+	done := NewDeployment(DeploymentTypeDeploy, "")
+	done.Finish(nil)
+	failed := NewDeployment(DeploymentTypeDeploy, "")
+	failed.Finish(errors.New("build failed"))
+	running := NewDeployment(DeploymentTypeRollback, DeployTriggerAPI)
+
+	app := AppSpec{History: []Deployment{done, failed, running}}
+	if !app.HasRunningDeployment() {
+		t.Fatal("expected a running deployment")
+	}
+
+	if count := app.InterruptRunningDeployments(); count != 1 {
+		t.Fatalf("interrupted %d deployments, want 1", count)
+	}
+	if app.HasRunningDeployment() {
+		t.Error("running deployment left after interrupt")
+	}
+
+	got := app.History[2]
+	if got.Status != DeploymentFailed || got.Error != ErrorInterrupted || got.FinishedAt == nil {
+		t.Errorf("got status=%s error=%q finishedAt=%v", got.Status, got.Error, got.FinishedAt)
+	}
+	if app.History[0].Status != DeploymentSuccess || app.History[1].Error != "build failed" {
+		t.Error("finished deployments changed")
+	}
+
+	if count := app.InterruptRunningDeployments(); count != 0 {
+		t.Errorf("second interrupt marked %d deployments", count)
+	}
+}
@@ -1 +1,8 @@
 package domain
+
+type Env struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Secret 敏感值，所有输出（CLI / API / export）中隐藏
+	Secret bool `json:"secret,omitempty"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+// dockflow 管理的容器上的 label，用于 monitor 查找应用与 reconcile 识别孤儿容器
+const (
+	LabelNamespace = "dockflow.namespace"
+	LabelName      = "dockflow.name"
+	LabelVersion   = "dockflow.version" // 仅 app
+	LabelKind      = "dockflow.kind"
+)
+
+// ResourceKind dockflow.kind 的取值
+type ResourceKind string
+
+const (
+	ResourceApp      ResourceKind = "app"
+	ResourceRedis    ResourceKind = "redis"
+	ResourceDatabase ResourceKind = "database"
+)
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AppLink app 使用的 redis / database，运行时注入连接信息
+type AppLink struct {
+	Kind   ResourceKind `json:"kind"` // redis | database
+	Name   string       `json:"name"`
+	Prefix string       `json:"prefix,omitempty"` // 注入的变量名前缀，同一类型链接多个时区分
+}
+
+var linkPrefixPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+func (l AppLink) Validate() error {
+	switch l.Kind {
+	case ResourceRedis, ResourceDatabase:
+	default:
+		return fmt.Errorf("invalid link kind: %s", l.Kind)
+	}
+	if l.Name == "" {
+		return fmt.Errorf("link name is required")
+	}
+	if l.Prefix != "" && !linkPrefixPattern.MatchString(l.Prefix) {
+		return fmt.Errorf("invalid link prefix: %s (expect upper case letters, digits and _)", l.Prefix)
+	}
+	return nil
+}
+
+// EnvKey 加上前缀的变量名，如 CACHE + REDIS_URL → CACHE_REDIS_URL
+func (l AppLink) EnvKey(key string) string {
+	if l.Prefix == "" {
+		return key
+	}
+	return strings.TrimSuffix(l.Prefix, "_") + "_" + key
+}
+
+func (l AppLink) String() string {
+	if l.Prefix == "" {
+		return fmt.Sprintf("%s/%s", l.Kind, l.Name)
+	}
+	return fmt.Sprintf("%s/%s (%s)", l.Kind, l.Name, l.Prefix)
+}
+
+// LinkedApps 链接了某个 redis / database 的 app
+func (n *Namespace) LinkedApps(kind ResourceKind, name string) []string {
+	var apps []string
+	for _, app := range n.App {
+		for _, link := range app.Links {
+			if link.Kind == kind && link.Name == name {
+				apps = append(apps, app.Name)
+				break
+			}
+		}
+	}
+	return apps
+}
+
+// FindRedis / FindDatabase 按名称查找
+func (n *Namespace) FindRedis(name string) (RedisSpec, bool) {
+	for _, redis := range n.Redis {
+		if redis.Name == name {
+			return redis, true
+		}
+	}
+	return RedisSpec{}, false
+}
+
+func (n *Namespace) FindDatabase(name string) (DatabaseSpec, bool) {
+	for _, database := range n.Database {
+		if database.Name == name {
+			return database, true
+		}
+	}
+	return DatabaseSpec{}, false
+}
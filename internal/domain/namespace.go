@@ -2,6 +2,7 @@ package domain
 
 import (
 	"dockflow/internal/service/filesystem"
+	"dockflow/internal/state"
 	"encoding/json"
 	"errors"
 	"os"
@@ -21,27 +22,26 @@ type Namespace struct {
 	Redis     []RedisSpec    `json:"redis"`
 	Database  []DatabaseSpec `json:"database"`
 	App       []AppSpec      `json:"app"`
+	// Revision 每次写入加 1，写入时与磁盘上的版本不一致则拒绝
+	Revision int64 `json:"revision"`
 }
 
 var (
 	ErrNamespaceNotFound       = errors.New("namespace not found")
 	ErrNamespaceNotInitialized = errors.New("namespace not initialized")
+	ErrNamespaceStale          = errors.New("namespace was modified by another process, reload and retry")
 )
 
 func ListNamespaces() []Namespace {
 	var result []Namespace
 
-	entries, err := os.ReadDir(filesystem.NamespaceDirName)
+	names, err := state.Default().List(state.KindNamespace)
 	if err != nil {
 		return result
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-
-		ns, err := NewNamespace(entry.Name())
+	for _, name := range names {
+		ns, err := NewNamespace(name)
 		if err == nil {
 			result = append(result, *ns)
 		}
@@ -51,38 +51,44 @@ func ListNamespaces() []Namespace {
 }
 
 func NewNamespace(name string) (*Namespace, error) {
-	dir := namespaceDir(name)
-
-	// 1️⃣ 判断 namespace 目录是否存在
-	if _, err := os.Stat(dir); err != nil {
-		if os.IsNotExist(err) {
+	data, err := state.Default().Get(state.KindNamespace, name)
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
 			return nil, ErrNamespaceNotFound
 		}
 		return nil, err
 	}
+	return decodeNamespace(name, data)
+}
 
-	file := namespaceFile(name)
+// UpdateNamespace 在存储的事务 / 排他锁内完成 读取 → 修改 → 写入，fn 返回错误时不写入
+func UpdateNamespace(name string, fn func(ns *Namespace) error) error {
+	return state.Default().Update(state.KindNamespace, name, func(old []byte) ([]byte, error) {
+		if old == nil {
+			return nil, ErrNamespaceNotFound
+		}
 
-	// 2️⃣ 判断 namespace.json 是否存在
-	if _, err := os.Stat(file); err != nil {
-		if os.IsNotExist(err) {
-			return nil, ErrNamespaceNotInitialized
+		ns, err := decodeNamespace(name, old)
+		if err != nil {
+			return nil, err
 		}
-		return nil, err
-	}
 
-	// 3️⃣ 读取并解析
-	data, err := os.ReadFile(file)
-	if err != nil {
-		return nil, err
-	}
+		if err := fn(ns); err != nil {
+			return nil, err
+		}
+
+		ns.Revision++
+		return json.MarshalIndent(ns, "", "  ")
+	})
+}
 
+func decodeNamespace(name string, data []byte) (*Namespace, error) {
 	var ns Namespace
 	if err := json.Unmarshal(data, &ns); err != nil {
 		return nil, err
 	}
 
-	// 4️⃣ 容错：name 不一致时自动修正
+	// 容错：name 不一致时自动修正
 	if ns.Name == "" {
 		ns.Name = name
 	}
@@ -103,29 +109,42 @@ func (n *Namespace) RemoveApp(appName string) {
 	if found {
 		n.App = append(n.App[:index], n.App[index+1:]...)
 	}
-	n.Save()
 }
 
+// Save 写入 namespace 状态
+// n 是读取后修改的，期间其他进程已写入（Revision 不一致）时返回 ErrNamespaceStale
+// 读取 → 修改 → 写入 的场景优先使用 UpdateNamespace
 func (n *Namespace) Save() error {
 	if n.Name == "" {
 		return errors.New("namespace name required")
 	}
 
-	dir := namespaceDir(n.Name)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	// 构建日志等非状态文件仍在 namespace 目录下
+	if err := os.MkdirAll(namespaceDir(n.Name), 0755); err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(n, "", "  ")
+	next := *n
+	err := state.Default().Update(state.KindNamespace, n.Name, func(old []byte) ([]byte, error) {
+		if old != nil {
+			current, err := decodeNamespace(n.Name, old)
+			if err != nil {
+				return nil, err
+			}
+			if current.Revision != n.Revision {
+				return nil, ErrNamespaceStale
+			}
+		}
+
+		next.Revision++
+		return json.MarshalIndent(next, "", "  ")
+	})
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(
-		namespaceFile(n.Name),
-		data,
-		0644,
-	)
+	n.Revision = next.Revision
+	return nil
 }
 
 func (n *Namespace) Remove() error {
@@ -133,13 +152,12 @@ func (n *Namespace) Remove() error {
 		return errors.New("namespace name required")
 	}
 
+	if err := state.Default().Delete(state.KindNamespace, n.Name); err != nil {
+		return err
+	}
 	return os.RemoveAll(namespaceDir(n.Name))
 }
 
 func namespaceDir(name string) string {
 	return filepath.Join(filesystem.NamespaceDirName, name)
 }
-
-func namespaceFile(name string) string {
-	return filepath.Join(namespaceDir(name), "namespace.json")
-}
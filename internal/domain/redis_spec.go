@@ -35,3 +35,6 @@ func NewRedisSpace(
 		Eviction:  eviction,
 	}
 }
+
+// RedisPort redis 容器内监听端口
+const RedisPort = "6379"
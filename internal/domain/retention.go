@@ -0,0 +1,63 @@
+package domain
+
+import "sort"
+
+// DefaultKeepVersions 未配置保留数量时保留的版本数
+const DefaultKeepVersions = 5
+
+// Retention 版本保留策略，超出的版本由 gc 删除容器、镜像与部署记录
+type Retention struct {
+	KeepVersions int `json:"keepVersions"` // 保留最近 N 个版本，不含 latest
+}
+
+// KeepVersions 应用配置的保留数量，未配置时使用 def（daemon 配置），def <= 0 时使用默认值
+func (a AppSpec) KeepVersions(def int) int {
+	if a.Retention != nil && a.Retention.KeepVersions > 0 {
+		return a.Retention.KeepVersions
+	}
+	if def > 0 {
+		return def
+	}
+	return DefaultKeepVersions
+}
+
+// ExpiredVersions 超出保留数量、可以回收的版本记录，按部署时间从旧到新
+// 以下版本始终保留：
+// - latest 当前运行的版本
+// - latest 由回滚产生时被替换的版本
+// - 当前版本的上一个版本（默认回滚目标）
+// - 最近 keep 个版本
+func (a AppSpec) ExpiredVersions(keep int) []AppDeploy {
+	var versions []AppDeploy
+	for _, deploy := range a.Deploy {
+		if deploy.Version != "latest" {
+			versions = append(versions, deploy)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].DeployedAt.Before(versions[j].DeployedAt)
+	})
+
+	protected := map[string]bool{}
+	if latest, ok := a.Latest(); ok {
+		current := latest.ImageVersion()
+		protected[current] = true
+		protected[latest.RollbackFrom] = true
+		for i, v := range versions {
+			if v.Version == current && i > 0 {
+				protected[versions[i-1].Version] = true
+			}
+		}
+	}
+	for i := len(versions) - 1; i >= 0 && i >= len(versions)-keep; i-- {
+		protected[versions[i].Version] = true
+	}
+
+	var expired []AppDeploy
+	for _, v := range versions {
+		if !protected[v.Version] {
+			expired = append(expired, v)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testApp v1..vN 按顺序部署，latest 使用 current 的镜像
+func testApp(n int, current, rollbackFrom string) AppSpec {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	app := AppSpec{Name: "web"}
+	for i := 1; i <= n; i++ {
+		version := fmt.Sprintf("v%d", i)
+		app.Deploy = append(app.Deploy, AppDeploy{
+			Version:    version,
+			Image:      "web:" + version,
+			DeployedAt: base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	if current != "" {
+		app.Deploy = append(app.Deploy, AppDeploy{
+			Version:      "latest",
+			Image:        "web:" + current,
+			DeployedAt:   base.Add(time.Duration(n+1) * time.Hour),
+			RollbackFrom: rollbackFrom,
+		})
+	}
+	return app
+}
+
+func versionsOf(deploys []AppDeploy) []string {
+	versions := []string{}
+	for _, d := range deploys {
+		versions = append(versions, d.Version)
+	}
+	return versions
+}
+
+func TestExpiredVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		app  AppSpec
+		keep int
+		want []string
+	}{
+		{
+			name: "within keep",
+			app:  testApp(3, "v3", ""),
+			keep: 5,
+			want: []string{},
+		},
+		{
+			name: "oldest versions expire",
+			app:  testApp(8, "v8", ""),
+			keep: 3,
+			want: []string{"v1", "v2", "v3", "v4", "v5"},
+		},
+		{
+			name: "current version after rollback and its previous version are kept",
+			app:  testApp(8, "v3", ""),
+			keep: 2,
+			want: []string{"v1", "v4", "v5", "v6"},
+		},
+		{
+			name: "version replaced by rollback is kept",
+			app:  testApp(8, "v3", "v1"),
+			keep: 2,
+			want: []string{"v4", "v5", "v6"},
+		},
+		{
+			name: "keep 1 still keeps the rollback target",
+			app:  testApp(4, "v4", ""),
+			keep: 1,
+			want: []string{"v1", "v2"},
+		},
+		{
+			name: "no latest keeps only the newest",
+			app:  testApp(4, "", ""),
+			keep: 2,
+			want: []string{"v1", "v2"},
+		},
+		{
+			name: "keep 0 keeps only protected versions",
+			app:  testApp(4, "v4", ""),
+			keep: 0,
+			want: []string{"v1", "v2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := versionsOf(tt.app.ExpiredVersions(tt.keep))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpiredVersionsOrdersByDeployTime(t *testing.T) {
+	app := testApp(5, "v5", "")
+	// 记录顺序与部署时间不一致时按部署时间计算
+	app.Deploy[0], app.Deploy[3] = app.Deploy[3], app.Deploy[0]
+
+	got := versionsOf(app.ExpiredVersions(2))
+	want := []string{"v1", "v2", "v3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestKeepVersions(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention *Retention
+		def       int
+		want      int
+	}{
+		{name: "app setting wins", retention: &Retention{KeepVersions: 2}, def: 10, want: 2},
+		{name: "daemon default", def: 10, want: 10},
+		{name: "zero app setting uses daemon default", retention: &Retention{}, def: 7, want: 7},
+		{name: "built-in default", want: DefaultKeepVersions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := AppSpec{Retention: tt.retention}
+			if got := app.KeepVersions(tt.def); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StackManifest 描述一个 namespace 下的全部资源，`dockflow apply -f` 的输入
+// 支持 YAML 与 JSON，字段名与 json tag 一致
+// 敏感字段（token / env value / password / username）支持 ${VAR} 引用环境变量
+type StackManifest struct {
+	Namespace string          `json:"namespace"`
+	Apps      []StackApp      `json:"apps,omitempty"`
+	Redis     []StackRedis    `json:"redis,omitempty"`
+	Databases []StackDatabase `json:"databases,omitempty"`
+}
+
+type StackApp struct {
+	Name      string       `json:"name"`
+	Repo      string       `json:"repo"`
+	Token     string       `json:"token,omitempty"`
+	CPU       float64      `json:"cpu,omitempty"`    // default 1
+	Memory    int          `json:"memory,omitempty"` // GB, default 1
+	Trigger   *Trigger     `json:"trigger,omitempty"`
+	Env       []Env        `json:"env,omitempty"`
+	URLs      []AppURL     `json:"urls"`
+	Health    *HealthCheck `json:"health,omitempty"`
+	Links     []AppLink    `json:"links,omitempty"`
+	Retention *Retention   `json:"retention,omitempty"` // default daemon keep_versions
+}
+
+type StackRedis struct {
+	Name     string  `json:"name"`
+	Version  string  `json:"version,omitempty"` // default 7
+	CPU      float64 `json:"cpu,omitempty"`     // default 0.5
+	Memory   float64 `json:"memory,omitempty"`  // GB, default 0.5
+	Password string  `json:"password,omitempty"`
+	AOF      *bool   `json:"aof,omitempty"`      // default true
+	Eviction string  `json:"eviction,omitempty"` // default allkeys-lru
+}
+
+type StackDatabase struct {
+	Name     string  `json:"name"`
+	DbType   string  `json:"dbType,omitempty"` // default mysql:5.7
+	CPU      float64 `json:"cpu,omitempty"`    // default 1
+	Memory   float64 `json:"memory,omitempty"` // GB, default 2
+	Username string  `json:"username"`
+	Password string  `json:"password"`
+	DbName   string  `json:"dbName"`
+	Remote   bool    `json:"remote,omitempty"`
+}
+
+// ParseStackManifest 解析 YAML / JSON（JSON 是 YAML 的子集）
+// 先转成通用结构再按 json tag 解码，两种格式使用同一套字段名
+func ParseStackManifest(data []byte) (*StackManifest, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+
+	var m StackManifest
+	dec := json.NewDecoder(bytes.NewReader(normalized))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *StackManifest) Validate() error {
+	if m.Namespace == "" {
+		return fmt.Errorf("manifest namespace is required")
+	}
+
+	seen := map[string]bool{}
+	unique := func(kind, name string) error {
+		if name == "" {
+			return fmt.Errorf("%s name is required", kind)
+		}
+		if seen[kind+"/"+name] {
+			return fmt.Errorf("duplicate %s [%s]", kind, name)
+		}
+		seen[kind+"/"+name] = true
+		return nil
+	}
+
+	for _, app := range m.Apps {
+		if err := unique("app", app.Name); err != nil {
+			return err
+		}
+		for _, link := range app.Links {
+			if err := link.Validate(); err != nil {
+				return fmt.Errorf("app [%s]: %w", app.Name, err)
+			}
+		}
+	}
+	for _, redis := range m.Redis {
+		if err := unique("redis", redis.Name); err != nil {
+			return err
+		}
+	}
+	for _, database := range m.Databases {
+		if err := unique("database", database.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var stackVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandVars 展开敏感字段中的 ${VAR}，所有缺失的变量一起报错
+func (m *StackManifest) ExpandVars(lookup func(string) (string, bool)) error {
+	missing := map[string]bool{}
+	expand := func(s *string) {
+		*s = stackVarPattern.ReplaceAllStringFunc(*s, func(ref string) string {
+			name := stackVarPattern.FindStringSubmatch(ref)[1]
+			value, ok := lookup(name)
+			if !ok {
+				missing[name] = true
+				return ref
+			}
+			return value
+		})
+	}
+
+	for i := range m.Apps {
+		expand(&m.Apps[i].Token)
+		for j := range m.Apps[i].Env {
+			expand(&m.Apps[i].Env[j].Value)
+		}
+	}
+	for i := range m.Redis {
+		expand(&m.Redis[i].Password)
+	}
+	for i := range m.Databases {
+		expand(&m.Databases[i].Username)
+		expand(&m.Databases[i].Password)
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("manifest references unset variables: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// AppSpec 填充默认值，默认值与 CLI app create 一致
+func (a StackApp) AppSpec(namespace string) AppSpec {
+	spec := AppSpec{
+		Namespace: namespace,
+		Name:      a.Name,
+		CPU:       a.CPU,
+		Memory:    a.Memory,
+		Repo:      a.Repo,
+		Token:     a.Token,
+		Trigger:   Trigger{Type: "branch", Rule: "main"},
+		Envs:      a.Env,
+		URLs:      a.URLs,
+		Health:    a.Health,
+		Links:     a.Links,
+		Retention: a.Retention,
+	}
+	if spec.CPU == 0 {
+		spec.CPU = 1
+	}
+	if spec.Memory == 0 {
+		spec.Memory = 1
+	}
+	if a.Trigger != nil {
+		spec.Trigger = *a.Trigger
+	}
+	if spec.Envs == nil {
+		spec.Envs = []Env{}
+	}
+	return spec
+}
+
+// RedisSpec 填充默认值，默认值与 CLI redis create 一致
+func (r StackRedis) RedisSpec(namespace string) RedisSpec {
+	spec := NewRedisSpace(r.Name, namespace, r.Password, r.CPU, r.Memory, r.Version, true, r.Eviction)
+	if spec.CPU == 0 {
+		spec.CPU = 0.5
+	}
+	if spec.Memory == 0 {
+		spec.Memory = 0.5
+	}
+	if spec.Version == "" {
+		spec.Version = "7"
+	}
+	if r.AOF != nil {
+		spec.AOF = *r.AOF
+	}
+	if spec.Eviction == "" {
+		spec.Eviction = "allkeys-lru"
+	}
+	return spec
+}
+
+// DatabaseSpec 填充默认值，默认值与 CLI database create 一致
+func (d StackDatabase) DatabaseSpec(namespace string) DatabaseSpec {
+	spec := DatabaseSpec{
+		Namespace: namespace,
+		Name:      d.Name,
+		CPU:       d.CPU,
+		Memory:    d.Memory,
+		Username:  d.Username,
+		Password:  d.Password,
+		DbName:    d.DbName,
+		DbType:    d.DbType,
+		Remote:    d.Remote,
+	}
+	if spec.CPU == 0 {
+		spec.CPU = 1
+	}
+	if spec.Memory == 0 {
+		spec.Memory = 2
+	}
+	if spec.DbType == "" {
+		spec.DbType = "mysql:5.7"
+	}
+	return spec
+}
+
+//
+// ==========================
+// Export
+// ==========================
+//
+
+// secretEnvKeywords env key 包含这些词时视为敏感值，导出为 ${VAR} 引用
+var secretEnvKeywords = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL", "DSN"}
+
+// ExportStackManifest 将 namespace 导出为 manifest，是 apply 的逆过程
+// - 不包含容器 ID、IP、网络、子网、部署记录等与主机相关的字段
+// - token / password / secret env / 疑似敏感的 env 导出为 ${VAR} 引用，返回所有引用的变量名
+func ExportStackManifest(ns Namespace) (StackManifest, []string) {
+	m := StackManifest{Namespace: ns.Name}
+
+	var vars []string
+	ref := func(value string, parts ...string) string {
+		if value == "" {
+			return ""
+		}
+		name := stackVarName(append([]string{ns.Name}, parts...)...)
+		vars = append(vars, name)
+		return "${" + name + "}"
+	}
+
+	for _, app := range ns.App {
+		trigger := app.Trigger
+		stackApp := StackApp{
+			Name:      app.Name,
+			Repo:      app.Repo,
+			Token:     ref(app.Token, app.Name, "TOKEN"),
+			CPU:       app.CPU,
+			Memory:    app.Memory,
+			Trigger:   &trigger,
+			URLs:      app.URLs,
+			Health:    app.Health,
+			Links:     app.Links,
+			Retention: app.Retention,
+		}
+		for _, env := range app.Envs {
+			if env.Secret || isSecretEnvKey(env.Key) {
+				env.Value = ref(env.Value, app.Name, env.Key)
+			}
+			stackApp.Env = append(stackApp.Env, env)
+		}
+		m.Apps = append(m.Apps, stackApp)
+	}
+
+	for _, redis := range ns.Redis {
+		aof := redis.AOF
+		m.Redis = append(m.Redis, StackRedis{
+			Name:     redis.Name,
+			Version:  redis.Version,
+			CPU:      redis.CPU,
+			Memory:   redis.Memory,
+			Password: ref(redis.Password, "REDIS", redis.Name, "PASSWORD"),
+			AOF:      &aof,
+			Eviction: redis.Eviction,
+		})
+	}
+
+	for _, database := range ns.Database {
+		m.Databases = append(m.Databases, StackDatabase{
+			Name:     database.Name,
+			DbType:   database.DbType,
+			CPU:      database.CPU,
+			Memory:   database.Memory,
+			Username: database.Username,
+			Password: ref(database.Password, "DB", database.Name, "PASSWORD"),
+			DbName:   database.DbName,
+			Remote:   database.Remote,
+		})
+	}
+
+	return m, vars
+}
+
+func isSecretEnvKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, keyword := range secretEnvKeywords {
+		if strings.Contains(upper, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// stackVarName 拼接为大写的环境变量名，非字母数字替换为 _
+func stackVarName(parts ...string) string {
+	name := strings.ToUpper(strings.Join(parts, "_"))
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// YAML 按 json tag 的字段名和顺序输出 YAML，与 ParseStackManifest 对应
+func (m StackManifest) YAML() ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	resetYAMLStyle(&node)
+
+	return yaml.Marshal(&node)
+}
+
+// resetYAMLStyle JSON 解析出的节点是 flow 风格，改为 block 风格
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
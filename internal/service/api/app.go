@@ -0,0 +1,151 @@
+package api
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service/queue"
+	"dockflow/internal/usecase"
+	"dockflow/internal/util"
+	"net/http"
+)
+
+// deployQueue daemon 的部署队列，API 触发的部署与 webhook 共用
+type deployQueue interface {
+	Enqueue(job queue.Job) error
+	List() []queue.Job
+}
+
+// maskApp 隐藏 git token、webhook secret 与 secret env
+func maskApp(app domain.AppSpec) domain.AppSpec {
+	app.Token = util.MaskSecret(app.Token)
+	app.Secret = util.MaskSecret(app.Secret)
+	app.Envs = util.MaskEnvs(app.Envs)
+	return app
+}
+
+func (h *handler) listApps(w http.ResponseWriter, r *http.Request) {
+	apps, err := usecase.ListApp(r.PathValue("ns"))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	views := make([]domain.AppSpec, 0, len(apps))
+	for _, app := range apps {
+		views = append(views, maskApp(app))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (h *handler) createApp(w http.ResponseWriter, r *http.Request) {
+	var app domain.AppSpec
+	if !decodeBody(w, r, &app) {
+		return
+	}
+	// namespace 以路径为准，部署记录只能由 daemon 写入
+	app.Namespace = r.PathValue("ns")
+	app.Deploy = nil
+	app.History = nil
+
+	result, err := usecase.CreateApp(app)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	// webhook secret 只在创建时返回明文，用于配置仓库的 webhook
+	view := maskApp(result.App)
+	view.Secret = result.WebhookSecret
+	writeJSON(w, http.StatusCreated, view)
+}
+
+func (h *handler) removeApp(w http.ResponseWriter, r *http.Request) {
+	if err := usecase.RemoveApp(r.PathValue("ns"), r.PathValue("app")); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type deployAppRequest struct {
+	Branch string `json:"branch"`
+	Commit string `json:"commit"`
+	Tag    string `json:"tag"`
+}
+
+// deployApp 加入部署队列后立即返回，进度通过 /v1/queue 与 app history 查看
+func (h *handler) deployApp(w http.ResponseWriter, r *http.Request) {
+	var req deployAppRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	nsName, appName := r.PathValue("ns"), r.PathValue("app")
+	if _, err := findApp(nsName, appName); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	job := queue.Job{
+		Namespace: nsName,
+		App:       appName,
+		Branch:    req.Branch,
+		Commit:    req.Commit,
+		Tag:       req.Tag,
+		Trigger:   string(domain.DeployTriggerAPI),
+	}
+	if err := h.queue.Enqueue(job); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+type rollbackAppRequest struct {
+	To string `json:"to"`
+}
+
+// rollbackApp 与部署一样加入队列，不会与同一应用进行中的部署同时执行
+// 结果通过 /v1/queue 与 app history 查看
+func (h *handler) rollbackApp(w http.ResponseWriter, r *http.Request) {
+	var req rollbackAppRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	nsName, appName := r.PathValue("ns"), r.PathValue("app")
+	if _, err := findApp(nsName, appName); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	job := queue.Job{
+		Namespace:  nsName,
+		App:        appName,
+		Trigger:    string(domain.DeployTriggerAPI),
+		Type:       queue.JobRollback,
+		RollbackTo: req.To,
+	}
+	if err := h.queue.Enqueue(job); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (h *handler) listQueue(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.queue.List())
+}
+
+func findApp(nsName, appName string) (*domain.AppSpec, error) {
+	ns, err := domain.NewNamespace(nsName)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, usecase.ErrNamespaceNotFound
+	}
+	app, found := ns.FindApp(appName)
+	if !found {
+		return nil, usecase.ErrAppNotFound
+	}
+	return &app, nil
+}
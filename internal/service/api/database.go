@@ -0,0 +1,125 @@
+package api
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/usecase"
+	"dockflow/internal/util"
+	"errors"
+	"net/http"
+)
+
+type databaseView struct {
+	Namespace   string   `json:"namespace"`
+	Name        string   `json:"name"`
+	CPU         float64  `json:"cpu"`
+	Memory      float64  `json:"memory"`
+	Username    string   `json:"username"`
+	Password    string   `json:"password"`
+	DbName      string   `json:"dbName"`
+	DbType      string   `json:"dbType"`
+	ContainerId string   `json:"containerId"`
+	Ip          []string `json:"ip"`
+	Remote      bool     `json:"remote"`
+}
+
+func newDatabaseView(database domain.DatabaseSpec) databaseView {
+	return databaseView{
+		Namespace:   database.Namespace,
+		Name:        database.Name,
+		CPU:         database.CPU,
+		Memory:      database.Memory,
+		Username:    database.Username,
+		Password:    util.MaskSecret(database.Password),
+		DbName:      database.DbName,
+		DbType:      database.DbType,
+		ContainerId: database.ContainerId,
+		Ip:          database.Ip,
+		Remote:      database.Remote,
+	}
+}
+
+// createDatabaseRequest 默认值与 CLI database create 一致
+type createDatabaseRequest struct {
+	Name     string   `json:"name"`
+	CPU      *float64 `json:"cpu"`
+	Memory   *float64 `json:"memory"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	DbName   string   `json:"dbName"`
+	DbType   string   `json:"dbType"`
+	Remote   bool     `json:"remote"`
+}
+
+func (req createDatabaseRequest) validate() error {
+	switch {
+	case req.Name == "":
+		return errors.New("name is required")
+	case req.Username == "":
+		return errors.New("username is required")
+	case req.Password == "":
+		return errors.New("password is required")
+	case req.DbName == "":
+		return errors.New("dbName is required")
+	}
+	return nil
+}
+
+func (h *handler) listDatabases(w http.ResponseWriter, r *http.Request) {
+	list, err := usecase.Listdatabase(r.PathValue("ns"))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	views := make([]databaseView, 0, len(list))
+	for _, database := range list {
+		views = append(views, newDatabaseView(database))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (h *handler) createDatabase(w http.ResponseWriter, r *http.Request) {
+	var req createDatabaseRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	database := domain.DatabaseSpec{
+		Namespace: r.PathValue("ns"),
+		Name:      req.Name,
+		CPU:       1,
+		Memory:    2,
+		Username:  req.Username,
+		Password:  req.Password,
+		DbName:    req.DbName,
+		DbType:    req.DbType,
+		Remote:    req.Remote,
+	}
+	if req.CPU != nil {
+		database.CPU = *req.CPU
+	}
+	if req.Memory != nil {
+		database.Memory = *req.Memory
+	}
+	if database.DbType == "" {
+		database.DbType = "mysql:5.7"
+	}
+
+	if err := usecase.Createdatabase(database); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newDatabaseView(database))
+}
+
+func (h *handler) removeDatabase(w http.ResponseWriter, r *http.Request) {
+	if err := usecase.Removedatabase(r.PathValue("ns"), r.PathValue("name")); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
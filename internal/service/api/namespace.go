@@ -0,0 +1,89 @@
+package api
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/usecase"
+	"errors"
+	"net/http"
+	"time"
+)
+
+type handler struct {
+	queue deployQueue
+}
+
+type namespaceView struct {
+	Name      string    `json:"name"`
+	Network   string    `json:"network"`
+	Subnet    string    `json:"subnet"`
+	Gateway   string    `json:"gateway"`
+	CreatedAt time.Time `json:"createdAt"`
+	Apps      []string  `json:"apps"`
+	Redis     []string  `json:"redis"`
+	Databases []string  `json:"databases"`
+}
+
+func newNamespaceView(ns domain.Namespace) namespaceView {
+	view := namespaceView{
+		Name:      ns.Name,
+		Network:   ns.Network,
+		Subnet:    ns.Subnet,
+		Gateway:   ns.Gateway,
+		CreatedAt: ns.CreatedAt,
+		Apps:      []string{},
+		Redis:     []string{},
+		Databases: []string{},
+	}
+	for _, app := range ns.App {
+		view.Apps = append(view.Apps, app.Name)
+	}
+	for _, redis := range ns.Redis {
+		view.Redis = append(view.Redis, redis.Name)
+	}
+	for _, database := range ns.Database {
+		view.Databases = append(view.Databases, database.Name)
+	}
+	return view
+}
+
+func (h *handler) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *handler) listNamespaces(w http.ResponseWriter, r *http.Request) {
+	views := []namespaceView{}
+	for _, ns := range usecase.ListNamespace() {
+		views = append(views, newNamespaceView(ns))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+type createNamespaceRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *handler) createNamespace(w http.ResponseWriter, r *http.Request) {
+	var req createNamespaceRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+
+	ns, err := usecase.CreateNamespace(req.Name)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newNamespaceView(*ns))
+}
+
+func (h *handler) removeNamespace(w http.ResponseWriter, r *http.Request) {
+	if err := usecase.RemoveNamespace(r.PathValue("ns")); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
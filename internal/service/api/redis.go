@@ -0,0 +1,105 @@
+package api
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/usecase"
+	"dockflow/internal/util"
+	"errors"
+	"net/http"
+)
+
+type redisView struct {
+	Name        string   `json:"name"`
+	Namespace   string   `json:"namespace"`
+	Version     string   `json:"version"`
+	CPU         float64  `json:"cpu"`
+	Memory      float64  `json:"memory"`
+	Password    string   `json:"password"`
+	AOF         bool     `json:"aof"`
+	Eviction    string   `json:"eviction"`
+	ContainerId string   `json:"containerId"`
+	Ip          []string `json:"ip"`
+}
+
+func newRedisView(redis domain.RedisSpec) redisView {
+	return redisView{
+		Name:        redis.Name,
+		Namespace:   redis.Namespace,
+		Version:     redis.Version,
+		CPU:         redis.CPU,
+		Memory:      redis.Memory,
+		Password:    util.MaskSecret(redis.Password),
+		AOF:         redis.AOF,
+		Eviction:    redis.Eviction,
+		ContainerId: redis.ContainerId,
+		Ip:          redis.Ip,
+	}
+}
+
+// createRedisRequest 默认值与 CLI redis create 一致
+type createRedisRequest struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	CPU      *float64 `json:"cpu"`
+	Memory   *float64 `json:"memory"`
+	Password string   `json:"password"`
+	AOF      *bool    `json:"aof"`
+	Eviction string   `json:"eviction"`
+}
+
+func (h *handler) listRedis(w http.ResponseWriter, r *http.Request) {
+	list, err := usecase.ListRedis(r.PathValue("ns"))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	views := make([]redisView, 0, len(list))
+	for _, redis := range list {
+		views = append(views, newRedisView(redis))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (h *handler) createRedis(w http.ResponseWriter, r *http.Request) {
+	var req createRedisRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+
+	cpu, memory, aof := 0.5, 0.5, true
+	if req.CPU != nil {
+		cpu = *req.CPU
+	}
+	if req.Memory != nil {
+		memory = *req.Memory
+	}
+	if req.AOF != nil {
+		aof = *req.AOF
+	}
+	if req.Version == "" {
+		req.Version = "7"
+	}
+	if req.Eviction == "" {
+		req.Eviction = "allkeys-lru"
+	}
+
+	redis := domain.NewRedisSpace(req.Name, r.PathValue("ns"), req.Password, cpu, memory, req.Version, aof, req.Eviction)
+	if err := usecase.CreateRedis(redis); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newRedisView(redis))
+}
+
+func (h *handler) removeRedis(w http.ResponseWriter, r *http.Request) {
+	if err := usecase.RemoveRedis(r.PathValue("ns"), r.PathValue("name")); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"dockflow/internal/usecase"
+	"dockflow/internal/util"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/samber/lo"
+)
+
+var supportGitRepo = []string{"github", "gitee", "gitlab"}
+
+type repoTokenView struct {
+	Repo  string `json:"repo"`
+	Url   string `json:"url,omitempty"`
+	Name  string `json:"name"`
+	Token string `json:"token"`
+}
+
+type repoTokenRequest struct {
+	Repo  string `json:"repo"`
+	Url   string `json:"url"`
+	Name  string `json:"name"`
+	Token string `json:"token"`
+}
+
+func (req repoTokenRequest) validate(needToken bool) error {
+	if !lo.Contains(supportGitRepo, req.Repo) {
+		return fmt.Errorf("repo [%s] not support, only support %v", req.Repo, supportGitRepo)
+	}
+	if req.Repo == "gitlab" && req.Url == "" {
+		return errors.New("repo gitlab must be set url")
+	}
+	if req.Name == "" {
+		return errors.New("name can't be blank")
+	}
+	if needToken && req.Token == "" {
+		return errors.New("token can't be blank")
+	}
+	return nil
+}
+
+func (req repoTokenRequest) toMap() map[string]string {
+	return map[string]string{
+		"url":   req.Url,
+		"repo":  req.Repo,
+		"name":  req.Name,
+		"token": req.Token,
+	}
+}
+
+// listRepos token 只返回末尾 4 位
+func (h *handler) listRepos(w http.ResponseWriter, r *http.Request) {
+	git, err := usecase.RepoList()
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	views := []repoTokenView{}
+	for _, t := range git.Github {
+		views = append(views, repoTokenView{Repo: "github", Name: t.Name, Token: util.MaskSecret(t.Token)})
+	}
+	for _, t := range git.Gitee {
+		views = append(views, repoTokenView{Repo: "gitee", Name: t.Name, Token: util.MaskSecret(t.Token)})
+	}
+	for _, t := range git.Gitlab {
+		views = append(views, repoTokenView{Repo: "gitlab", Url: t.Url, Name: t.Name, Token: util.MaskSecret(t.Token)})
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (h *handler) addRepo(w http.ResponseWriter, r *http.Request) {
+	h.saveRepo(w, r, usecase.RepoAdd, http.StatusCreated)
+}
+
+func (h *handler) updateRepo(w http.ResponseWriter, r *http.Request) {
+	h.saveRepo(w, r, usecase.RepoUpdate, http.StatusOK)
+}
+
+func (h *handler) saveRepo(w http.ResponseWriter, r *http.Request, save func(map[string]string) error, status int) {
+	var req repoTokenRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if err := req.validate(true); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := save(req.toMap()); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeJSON(w, status, repoTokenView{
+		Repo:  req.Repo,
+		Url:   req.Url,
+		Name:  req.Name,
+		Token: util.MaskSecret(req.Token),
+	})
+}
+
+// removeRepo gitlab 通过 ?url= 指定实例
+func (h *handler) removeRepo(w http.ResponseWriter, r *http.Request) {
+	req := repoTokenRequest{
+		Repo: r.PathValue("repo"),
+		Name: r.PathValue("name"),
+		Url:  r.URL.Query().Get("url"),
+	}
+	if err := req.validate(false); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := usecase.RepoRemove(req.toMap()); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
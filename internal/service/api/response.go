@@ -0,0 +1,58 @@
+package api
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/usecase"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// maxBodyBytes 请求体大小上限
+const maxBodyBytes = 1 << 20
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeUsecaseError 按 usecase 的错误类型映射 HTTP 状态码
+func writeUsecaseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrNamespaceNotFound),
+		errors.Is(err, domain.ErrNamespaceNotFound),
+		errors.Is(err, usecase.ErrAppNotFound),
+		errors.Is(err, usecase.ErrRedisNotFound),
+		errors.Is(err, usecase.ErrRedisNotExist),
+		errors.Is(err, usecase.ErrdatabaseNotFound),
+		errors.Is(err, usecase.ErrdatabaseNotExist):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, usecase.ErrNamespaceExists),
+		errors.Is(err, usecase.ErrRedisExist),
+		errors.Is(err, usecase.ErrdatabaseExist),
+		errors.Is(err, usecase.ErrResourceLinked):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"dockflow/internal/service/filesystem"
+	"dockflow/internal/service/queue"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+var (
+	ErrAPITokenRequired = errors.New("api token is required when api_listen is set")
+)
+
+type Options struct {
+	// TCP 监听地址，为空时只监听 unix socket
+	Listen string
+	// TCP 监听的 bearer token
+	Token string
+
+	Queue *queue.DeployQueue
+}
+
+// Server daemon 管理 API
+// - unix socket：依赖文件权限，不做认证
+// - TCP（可选）：bearer token 认证
+type Server struct {
+	opt     Options
+	handler http.Handler
+	servers []*http.Server
+}
+
+func NewServer(opt Options) (*Server, error) {
+	if opt.Listen != "" && opt.Token == "" {
+		return nil, ErrAPITokenRequired
+	}
+
+	h := &handler{queue: opt.Queue}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", h.health)
+
+	// ---------- namespace ----------
+	mux.HandleFunc("GET /v1/namespaces", h.listNamespaces)
+	mux.HandleFunc("POST /v1/namespaces", h.createNamespace)
+	mux.HandleFunc("DELETE /v1/namespaces/{ns}", h.removeNamespace)
+
+	// ---------- app ----------
+	mux.HandleFunc("GET /v1/namespaces/{ns}/apps", h.listApps)
+	mux.HandleFunc("POST /v1/namespaces/{ns}/apps", h.createApp)
+	mux.HandleFunc("DELETE /v1/namespaces/{ns}/apps/{app}", h.removeApp)
+	mux.HandleFunc("POST /v1/namespaces/{ns}/apps/{app}/deploy", h.deployApp)
+	mux.HandleFunc("POST /v1/namespaces/{ns}/apps/{app}/rollback", h.rollbackApp)
+
+	// ---------- redis ----------
+	mux.HandleFunc("GET /v1/namespaces/{ns}/redis", h.listRedis)
+	mux.HandleFunc("POST /v1/namespaces/{ns}/redis", h.createRedis)
+	mux.HandleFunc("DELETE /v1/namespaces/{ns}/redis/{name}", h.removeRedis)
+
+	// ---------- database ----------
+	mux.HandleFunc("GET /v1/namespaces/{ns}/databases", h.listDatabases)
+	mux.HandleFunc("POST /v1/namespaces/{ns}/databases", h.createDatabase)
+	mux.HandleFunc("DELETE /v1/namespaces/{ns}/databases/{name}", h.removeDatabase)
+
+	// ---------- repo token ----------
+	mux.HandleFunc("GET /v1/repos", h.listRepos)
+	mux.HandleFunc("POST /v1/repos", h.addRepo)
+	mux.HandleFunc("PUT /v1/repos", h.updateRepo)
+	mux.HandleFunc("DELETE /v1/repos/{repo}/{name}", h.removeRepo)
+
+	// ---------- deploy queue ----------
+	mux.HandleFunc("GET /v1/queue", h.listQueue)
+
+	return &Server{
+		opt:     opt,
+		handler: recoverMiddleware(mux),
+	}, nil
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	// ---------- unix socket ----------
+	if err := os.MkdirAll(filesystem.APISocketDir, 0755); err != nil {
+		return err
+	}
+	// 上次异常退出残留的 socket
+	if err := os.Remove(filesystem.APISocket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	unixListener, err := net.Listen("unix", filesystem.APISocket)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(filesystem.APISocket, 0660); err != nil {
+		unixListener.Close()
+		return err
+	}
+	s.serve(unixListener, s.handler)
+
+	// ---------- tcp ----------
+	if s.opt.Listen != "" {
+		tcpListener, err := net.Listen("tcp", s.opt.Listen)
+		if err != nil {
+			return err
+		}
+		s.serve(tcpListener, bearerAuth(s.opt.Token, s.handler))
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Println("[api] shutting down")
+		for _, srv := range s.servers {
+			_ = srv.Shutdown(context.Background())
+		}
+	}()
+
+	return nil
+}
+
+func (s *Server) serve(listener net.Listener, handler http.Handler) {
+	srv := &http.Server{Handler: handler}
+	s.servers = append(s.servers, srv)
+
+	go func() {
+		log.Println("[api] listening on", listener.Addr().Network(), listener.Addr().String())
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Println("[api] error:", err)
+		}
+	}()
+}
+
+// bearerAuth TCP 监听的认证
+func bearerAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverMiddleware 单个请求 panic 时返回 500，daemon 继续服务
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("[api][panic] %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+				writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
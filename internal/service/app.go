@@ -1,18 +1,32 @@
 package service
 
 import (
+	buildtemplates "dockflow/build-templates"
 	"dockflow/internal/domain"
 	"dockflow/internal/service/docker"
 	"dockflow/internal/service/filesystem"
 	"dockflow/internal/service/git"
+	"dockflow/internal/service/manifest"
+	"dockflow/internal/service/secret"
 	"dockflow/internal/service/traefik"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/debug"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/samber/lo"
 )
 
 var (
 	ErrNamespaceNotFound = errors.New("namespace not found")
+	ErrDeployPanic       = errors.New("deploy panicked")
+	// build arg 的值会写入镜像历史（docker history），不能引用 secret
+	ErrSecretBuildArg = errors.New("secret:// is not allowed in build args, build args are visible in the image history")
 )
 
 //
@@ -50,13 +64,50 @@ func NewAppDeployer(app *domain.AppSpec) (*AppDeployer, error) {
 // ==========================
 //
 
-func (d *AppDeployer) Deploy(branch, commit, tag *string) error {
+// BuildOverrides 只对本次部署生效的构建设置，覆盖 app 中的设置
+type BuildOverrides struct {
+	Args     map[string]*string
+	Platform string
+}
+
+func (d *AppDeployer) Deploy(trigger domain.DeployTrigger, branch, commit, tag *string, overrides BuildOverrides) (err error) {
+	unlock, err := d.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// ---------- history ----------
+	record := domain.NewDeployment(domain.DeploymentTypeDeploy, trigger)
+	record.Branch = lo.FromPtr(branch)
+	record.Commit = lo.FromPtr(commit)
+	record.Tag = lo.FromPtr(tag)
+	if err := d.saveDeployment(record); err != nil {
+		return err
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = panicError(rec)
+		}
+		record.Finish(err)
+		if saveErr := d.saveDeployment(record); saveErr != nil {
+			log.Println("[deploy] save deployment record failed", saveErr)
+		}
+	}()
 
 	// ---------- git ----------
 	version, err := d.fetchAppCode(branch, commit, tag)
 	if err != nil {
 		return err
 	}
+	record.Version = version
+	if record.Commit == "" {
+		record.Commit = version
+	}
+	record.BuildLog = filesystem.BuildLogFile(d.app.Namespace, d.app.Name, version)
+	if err := d.saveDeployment(record); err != nil {
+		return err
+	}
 
 	containerId, err := docker.HasContainer(d.app.Name + "_" + version)
 	if err != nil {
@@ -75,7 +126,9 @@ func (d *AppDeployer) Deploy(branch, commit, tag *string) error {
 	}
 
 	// ---------- build ----------
-	image, err := d.buildApp(version)
+	buildStart := time.Now()
+	image, err := d.buildApp(version, overrides)
+	record.BuildDuration = time.Since(buildStart)
 	if err != nil {
 		return err
 	}
@@ -85,14 +138,41 @@ func (d *AppDeployer) Deploy(branch, commit, tag *string) error {
 		return err
 	}
 
-	// ---------- run latest ----------
-	if err := d.deployVersion(image, "latest"); err != nil {
+	// ---------- switch latest ----------
+	if err := d.cutoverLatest(image, ""); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// panicError 部署过程中的 panic 转换为错误并记录到部署记录
+func panicError(rec any) error {
+	log.Printf("[deploy][panic] %v\n%s", rec, debug.Stack())
+	return fmt.Errorf("%w: %v", ErrDeployPanic, rec)
+}
+
+// saveDeployment 写入部署记录，失败的部署同样保留记录
+func (d *AppDeployer) saveDeployment(record domain.Deployment) error {
+	return d.updateApp(func(app *domain.AppSpec) {
+		app.RecordDeployment(record)
+	})
+}
+
+// updateApp 部署流程只修改自己负责的字段（Deploy、History、NeedsRedeploy），
+// 写入基于最新状态，写入后 d.app 同步为最新状态
+func (d *AppDeployer) updateApp(fn func(app *domain.AppSpec)) error {
+	updated, err := domain.UpdateApp(d.app.Namespace, d.app.Name, func(app *domain.AppSpec) error {
+		fn(app)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*d.app = updated
+	return nil
+}
+
 //
 // ==========================
 // Namespace
@@ -141,46 +221,137 @@ func (d *AppDeployer) fetchAppCode(
 // ==========================
 //
 
-func (d *AppDeployer) buildApp(version string) (string, error) {
+func (d *AppDeployer) buildApp(version string, overrides BuildOverrides) (string, error) {
 
 	repoPath := filesystem.NamespaceDirName + "/" +
 		d.app.Namespace + "/repo/" + d.app.Name
 
-	// var args map[string]*string
-	// var err error
-	// switch d.app.Platform {
-	// case "java":
-	// 	args, err = manifest.ParseJavaMaven(repoPath)
-	// case "node-page":
-	// 	args = d.app.BuildArg
-	// case "go":
-	// 	args = map[string]*string{}
-	// case "python":
-	// 	args = map[string]*string{}
-	// default:
-	// 	err = fmt.Errorf("build type [%s] not support", d.app.Platform)
-	// }
-	// if err != nil {
-	// 	return "", err
-	// }
+	image := fmt.Sprintf("%s:%s", d.app.Name, version)
 
-	// ports := collectPorts(d.app.URLs)
-	// args["APP_PORT"] = &ports
+	logDir := filesystem.BuildLogDir(d.app.Namespace, d.app.Name)
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return "", err
+	}
+	logFile := filesystem.BuildLogFile(d.app.Namespace, d.app.Name, version)
+	file, err := os.Create(logFile)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
 
-	image := fmt.Sprintf("%s:%s", d.app.Name, version)
+	defer func() {
+		if err := filesystem.PruneBuildLogs(logDir, filesystem.MaxBuildLogBytes, logFile); err != nil {
+			log.Println("[build] prune build logs failed", err)
+		}
+	}()
+
+	out := io.MultiWriter(os.Stdout, file)
+
+	opt, err := d.buildOptions(repoPath, overrides, out)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return "", err
+	}
+	opt.Tag = image
+	opt.Labels = map[string]string{
+		domain.LabelNamespace: d.app.Namespace,
+		domain.LabelName:      d.app.Name,
+		domain.LabelVersion:   version,
+	}
 
-	if err := docker.Build(repoPath, image); err != nil {
+	if err := docker.Build(repoPath, opt, out); err != nil {
 		return "", err
 	}
 	return image, nil
 }
 
+// buildOptions 仓库有 Dockerfile 时直接使用，否则按检测到的技术栈使用构建模板
+// build args 按层合并，后者覆盖前者：检测值（仅模板）→ app BuildArg → 本次部署指定
+func (d *AppDeployer) buildOptions(repoPath string, overrides BuildOverrides, out io.Writer) (docker.BuildOptions, error) {
+	opt := docker.BuildOptions{
+		BuildArgs: map[string]*string{},
+		Platform:  lo.CoalesceOrEmpty(overrides.Platform, d.app.Platform),
+	}
+	if opt.Platform != "" {
+		fmt.Fprintf(out, "Building for platform %s\n", opt.Platform)
+	}
+
+	if _, err := os.Stat(repoPath + "/Dockerfile"); err != nil {
+		detected, err := manifest.Detect(repoPath)
+		if err != nil {
+			return opt, err
+		}
+
+		opt.Dockerfile, err = buildtemplates.Dockerfile(detected.Stack)
+		if err != nil {
+			return opt, err
+		}
+
+		fmt.Fprintf(out, "No Dockerfile found, detected %s %s from %s, building with template\n", detected.Stack, detected.Version, detected.File)
+		opt.BuildArgs = detected.Args
+		if ports := collectPorts(d.app.URLs); ports != "" {
+			opt.BuildArgs["APP_PORT"] = &ports
+		}
+		cacheID := buildCacheID(d.app)
+		opt.BuildArgs["CACHE_ID"] = &cacheID
+		printBuildArgs(out, opt.BuildArgs, "detected", true)
+	}
+
+	// app 与部署指定的值只输出 key
+	for _, layer := range []struct {
+		source string
+		args   map[string]*string
+	}{
+		{"app", d.app.BuildArg},
+		{"deploy", overrides.Args},
+	} {
+		if err := ValidateBuildArgs(layer.args); err != nil {
+			return opt, err
+		}
+		printBuildArgs(out, layer.args, layer.source, false)
+		for key, value := range layer.args {
+			opt.BuildArgs[key] = value
+		}
+	}
+
+	return opt, nil
+}
+
+// ValidateBuildArgs 拒绝 secret:// 引用，nil 值表示使用 daemon 的环境变量
+func ValidateBuildArgs(args map[string]*string) error {
+	for key, value := range args {
+		if value != nil && secret.IsRef(*value) {
+			return fmt.Errorf("build arg [%s]: %w", key, ErrSecretBuildArg)
+		}
+	}
+	return nil
+}
+
+func printBuildArgs(out io.Writer, args map[string]*string, source string, withValue bool) {
+	keys := lo.Keys(args)
+	sort.Strings(keys)
+	for _, key := range keys {
+		if withValue && args[key] != nil {
+			fmt.Fprintf(out, "  --build-arg %s=%s (%s)\n", key, *args[key], source)
+		} else {
+			fmt.Fprintf(out, "  --build-arg %s (%s)\n", key, source)
+		}
+	}
+}
+
+// buildCacheID 模板缓存挂载的 id 前缀
+// 模板声明 ARG CACHE_ID，RUN --mount=type=cache 的 id 写作 ${CACHE_ID}-<用途>（如 ${CACHE_ID}-npm），
+// 缓存挂载因此按 app 隔离、跨部署复用；模板中的默认值 dockflow 只在单独构建模板时使用
+func buildCacheID(app *domain.AppSpec) string {
+	return "dockflow-" + app.Namespace + "-" + app.Name
+}
+
 func collectPorts(urls []domain.AppURL) string {
 	var ports []string
 	for _, u := range urls {
 		ports = append(ports, u.Port)
 	}
-	return strings.Join(ports, " ")
+	return strings.Join(lo.Uniq(ports), " ")
 }
 
 //
@@ -194,18 +365,42 @@ func (d *AppDeployer) deployVersion(
 	version string,
 ) error {
 
-	containerId, err := d.runApp(image, version)
+	// ---------- cleanup ----------
+	if err := d.cleanupOldContainer(version); err != nil {
+		return err
+	}
+
+	containerName := fmt.Sprintf("%s_%s", d.app.Name, version)
+	containerId, err := d.runApp(image, version, containerName)
 	if err != nil {
 		return err
 	}
 
-	d.app.Deploy = append(d.app.Deploy, domain.AppDeploy{
+	entry := domain.AppDeploy{
 		ContainerId: containerId,
 		Version:     version,
 		Url:         "/" + version,
+		Image:       image,
+		DeployedAt:  time.Now(),
+	}
+	err = d.updateApp(func(app *domain.AppSpec) {
+		app.Deploy = append(app.Deploy, entry)
 	})
+	if err != nil {
+		return err
+	}
 
-	return domain.SaveApp(*d.app)
+	// 版本容器就绪后再挂路由；未就绪说明镜像有问题，不再切换 latest
+	// 未就绪的版本不保留记录，保证 Deploy 中的版本都可以作为回滚目标
+	ip, err := waitReady(containerId, appPorts(d.app.URLs), readyTimeout(d.app))
+	if err != nil {
+		if cleanupErr := d.cleanupOldContainer(version); cleanupErr != nil {
+			log.Println("[deploy] cleanup failed version", version, cleanupErr)
+		}
+		return fmt.Errorf("version [%s] not ready: %w", version, err)
+	}
+
+	return traefik.WriteAppRoutes(*d.app, version, ip)
 }
 
 //
@@ -214,14 +409,7 @@ func (d *AppDeployer) deployVersion(
 // ==========================
 //
 
-func (d *AppDeployer) runApp(image, version string) (string, error) {
-
-	containerName := fmt.Sprintf("%s_%s", d.app.Name, version)
-
-	// ---------- cleanup ----------
-	if err := d.cleanupOldContainer(version); err != nil {
-		return "", err
-	}
+func (d *AppDeployer) runApp(image, version, containerName string) (string, error) {
 
 	// ---------- run options ----------
 	opts := docker.NewRunOptions(containerName, image)
@@ -229,15 +417,36 @@ func (d *AppDeployer) runApp(image, version string) (string, error) {
 	opts.WithCpu(d.app.CPU)
 	opts.WithMemory(float64(d.app.Memory))
 
+	// 链接的 redis / database，app 自己配置的同名 env 优先
+	links, err := linkEnvs(d.ns, d.app.Links)
+	if err != nil {
+		return "", err
+	}
+	for _, env := range links {
+		if !lo.ContainsBy(d.app.Envs, func(e domain.Env) bool { return e.Key == env.Key }) {
+			opts.WithEnv(env.Key, env.Value)
+		}
+	}
+
+	// secret 只在注入容器时解密
 	for _, env := range d.app.Envs {
-		opts.WithEnv(env.Key, env.Value)
+		value, err := secret.Resolve(env.Value)
+		if err != nil {
+			return "", fmt.Errorf("env %s: %w", env.Key, err)
+		}
+		opts.WithEnv(env.Key, value)
+	}
+
+	if d.app.Health != nil {
+		d.applyHealthcheck(opts, *d.app.Health)
 	}
 
 	opts.WithNetwork(traefik.TraefikNetwork)
 	opts.WithNetwork(d.ns.Network)
-	opts.WithLabel("dockflow.namespace", d.ns.Name)
-	opts.WithLabel("dockflow.name", d.app.Name)
-	opts.WithLabel("dockflow.version", version)
+	opts.WithLabel(domain.LabelNamespace, d.ns.Name)
+	opts.WithLabel(domain.LabelName, d.app.Name)
+	opts.WithLabel(domain.LabelVersion, version)
+	opts.WithLabel(domain.LabelKind, string(domain.ResourceApp))
 
 	// ---------- traefik ----------
 	// opts.WithLabel("traefik.enable", "true")
@@ -262,6 +471,24 @@ func (d *AppDeployer) runApp(image, version string) (string, error) {
 	return docker.RunContainer(opts)
 }
 
+// applyHealthcheck HTTP 健康检查，容器内需要 wget 或 curl
+// 路径已由 HealthCheck.Validate 限制为 URL 字符，这里再用单引号包住，避免 & ? 等被 shell 解释
+func (d *AppDeployer) applyHealthcheck(opts *docker.ContainerRunOptions, health domain.HealthCheck) {
+	url := fmt.Sprintf("'http://127.0.0.1:%s%s'", d.app.HealthPort(), health.Path)
+
+	interval, timeout, startPeriod := health.Durations()
+	opts.WithHealthcheck(
+		[]string{
+			"CMD-SHELL",
+			fmt.Sprintf("wget -q -O /dev/null %[1]s || curl -fsS -o /dev/null %[1]s || exit 1", url),
+		},
+		interval,
+		timeout,
+		startPeriod,
+		health.RetriesOrDefault(),
+	)
+}
+
 //
 // ==========================
 // Cleanup
@@ -270,9 +497,7 @@ func (d *AppDeployer) runApp(image, version string) (string, error) {
 
 func (d *AppDeployer) cleanupOldContainer(version string) error {
 
-	for i := len(d.app.Deploy) - 1; i >= 0; i-- {
-		deploy := d.app.Deploy[i]
-
+	for _, deploy := range d.app.Deploy {
 		if deploy.Version != version {
 			continue
 		}
@@ -291,10 +516,13 @@ func (d *AppDeployer) cleanupOldContainer(version string) error {
 			}
 		}
 
-		d.app.Deploy = append(d.app.Deploy[:i], d.app.Deploy[i+1:]...)
 	}
 
-	return domain.SaveApp(*d.app)
+	return d.updateApp(func(app *domain.AppSpec) {
+		app.Deploy = lo.Filter(app.Deploy, func(deploy domain.AppDeploy, _ int) bool {
+			return deploy.Version != version
+		})
+	})
 }
 
 //
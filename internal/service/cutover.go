@@ -0,0 +1,216 @@
+package service
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service/docker"
+	"dockflow/internal/service/traefik"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// ReadyTimeout 新容器等待就绪的最短时间，配置了健康检查时按 readyTimeout 延长
+	ReadyTimeout = 2 * time.Minute
+	// DrainDelay 路由切换后等待 traefik 重新加载、旧连接处理完毕的时间
+	DrainDelay = 5 * time.Second
+	// StopTimeout 旧容器优雅停止的超时（秒）
+	StopTimeout = 10
+
+	readyPollInterval = time.Second
+)
+
+var (
+	ErrContainerNotReady = errors.New("container not ready")
+)
+
+// readyTimeout 等待就绪的时间：start period 之后还要经过 retries+1 次检查 docker 才会给出结果
+// 每次检查最长 interval + timeout，不少于 ReadyTimeout
+func readyTimeout(app *domain.AppSpec) time.Duration {
+	if app.Health == nil {
+		return ReadyTimeout
+	}
+	interval, timeout, startPeriod := app.Health.Durations()
+	retries := time.Duration(app.Health.RetriesOrDefault() + 1)
+	return max(ReadyTimeout, startPeriod+(interval+timeout)*retries)
+}
+
+//
+// ==========================
+// Blue / Green
+// ==========================
+//
+
+// cutoverLatest 零停机切换 latest：
+// 1. 新容器与旧容器并行启动
+// 2. 等待新容器就绪
+// 3. 路由切到新容器
+// 4. 旧容器排空后删除
+// 新容器未就绪时旧容器保持不变
+// rollbackFrom 非空时记录为从该版本回滚
+func (d *AppDeployer) cutoverLatest(image string, rollbackFrom string) error {
+	latestName := d.app.Name + "_latest"
+	candidateName := latestName + "_next"
+
+	// ---------- 清理上次失败残留的候选容器 ----------
+	if err := removeContainerByName(candidateName); err != nil {
+		return err
+	}
+
+	// ---------- 启动新容器 ----------
+	containerId, err := d.runApp(image, "latest", candidateName)
+	if err != nil {
+		return err
+	}
+
+	ip, err := waitReady(containerId, appPorts(d.app.URLs), readyTimeout(d.app))
+	if err != nil {
+		log.Printf("[deploy] app [%s] new latest not ready, keep old container: %v", d.app.Name, err)
+		_ = removeContainerByName(candidateName)
+		return fmt.Errorf("new latest container not ready, old container kept: %w", err)
+	}
+
+	// ---------- 切换路由 ----------
+	if err := traefik.WriteAppRoutes(*d.app, "latest", ip); err != nil {
+		_ = removeContainerByName(candidateName)
+		return err
+	}
+
+	var oldContainers []string
+	err = d.updateApp(func(app *domain.AppSpec) {
+		oldContainers = nil
+		deploys := make([]domain.AppDeploy, 0, len(app.Deploy))
+		for _, deploy := range app.Deploy {
+			if deploy.Version == "latest" {
+				oldContainers = append(oldContainers, deploy.ContainerId)
+				continue
+			}
+			deploys = append(deploys, deploy)
+		}
+		app.Deploy = append(deploys, domain.AppDeploy{
+			ContainerId:  containerId,
+			Version:      "latest",
+			Url:          "/latest",
+			Image:        image,
+			DeployedAt:   time.Now(),
+			RollbackFrom: rollbackFrom,
+		})
+		app.NeedsRedeploy = false
+	})
+	if err != nil {
+		return err
+	}
+
+	// ---------- 排空旧容器 ----------
+	if len(oldContainers) > 0 {
+		time.Sleep(DrainDelay)
+	}
+
+	timeout := StopTimeout
+	for _, old := range oldContainers {
+		id, err := docker.HasContainer(old)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			continue
+		}
+		if err := docker.StopContainer(id, &timeout); err != nil {
+			return err
+		}
+		if err := docker.RemoveContainer(id, true); err != nil {
+			return err
+		}
+	}
+
+	// ---------- 新容器改回正式名称 ----------
+	if id, err := docker.HasContainer(latestName); err != nil {
+		return err
+	} else if id != "" && id != containerId {
+		if err := docker.RemoveContainer(id, true); err != nil {
+			return err
+		}
+	}
+
+	return docker.RenameContainer(containerId, latestName)
+}
+
+//
+// ==========================
+// Readiness
+// ==========================
+//
+
+// waitReady 等待容器就绪，返回容器在 traefik 网络中的 IP
+// - 配置了 healthcheck：等待状态变为 healthy
+// - 未配置：等待所有 url 端口可以建立 TCP 连接
+func waitReady(containerId string, ports []string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		info, err := docker.InspectContainer(containerId)
+		if err != nil {
+			return "", err
+		}
+
+		if info.State == nil || (!info.State.Running && !info.State.Restarting) {
+			exitCode := 0
+			if info.State != nil {
+				exitCode = info.State.ExitCode
+			}
+			return "", fmt.Errorf("%w: container exited with code %d", ErrContainerNotReady, exitCode)
+		}
+
+		ip := docker.ContainerNetworkIP(info, traefik.TraefikNetwork)
+
+		if info.State.Running && ip != "" {
+			if info.State.Health != nil {
+				switch info.State.Health.Status {
+				case "healthy":
+					return ip, nil
+				case "unhealthy":
+					return "", fmt.Errorf("%w: container unhealthy", ErrContainerNotReady)
+				}
+			} else if portsReachable(ip, ports) {
+				return ip, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("%w: timeout after %s", ErrContainerNotReady, timeout)
+		}
+		time.Sleep(readyPollInterval)
+	}
+}
+
+func portsReachable(ip string, ports []string) bool {
+	for _, port := range ports {
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, port), time.Second)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+	}
+	return true
+}
+
+func appPorts(urls []domain.AppURL) []string {
+	return lo.Uniq(lo.Map(urls, func(u domain.AppURL, _ int) string {
+		return u.Port
+	}))
+}
+
+func removeContainerByName(name string) error {
+	id, err := docker.HasContainer(name)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return nil
+	}
+	return docker.RemoveContainer(id, true)
+}
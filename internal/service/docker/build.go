@@ -1,76 +1,41 @@
 package docker
 
 import (
-	"archive/tar"
-	"bytes"
 	"dockflow/internal/service/filesystem"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
 
 	"github.com/docker/docker/api/types"
 )
 
-// var BuildTypeEnum = []string{"go", "java", "node-page", "node-service", "php", "python"}
-
 var (
 	ErrorBuildTypeNotExist = errors.New("build type not exist")
 	ErrorBuildPathNotExist = errors.New("build path not exist")
 )
 
-func TarBuildContext(dir string) (io.Reader, error) {
-	buf := new(bytes.Buffer)
-	tw := tar.NewWriter(buf)
+// InjectedDockerfile 注入构建上下文的模板 Dockerfile 名称，避免覆盖仓库中的文件
+const InjectedDockerfile = ".dockflow.Dockerfile"
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+type BuildOptions struct {
+	Tag string
 
-		header, err := tar.FileInfoHeader(info, "")
-		if err != nil {
-			return err
-		}
+	// Dockerfile 不为空时作为 InjectedDockerfile 注入构建上下文并使用它构建
+	// 为空时使用仓库根目录的 Dockerfile
+	Dockerfile []byte
+	BuildArgs  map[string]*string
 
-		relPath, err := filepath.Rel(dir, path)
-		if err != nil {
-			return err
-		}
-		header.Name = relPath
-
-		if err := tw.WriteHeader(header); err != nil {
-			return err
-		}
-
-		if info.Mode().IsRegular() {
-			file, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
-
-			if _, err := io.Copy(tw, file); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	// Platform 目标平台，如 linux/arm64，为空时使用 daemon 的平台
+	Platform string
 
-	if err != nil {
-		return nil, err
-	}
-
-	if err := tw.Close(); err != nil {
-		return nil, err
-	}
-
-	return buf, nil
+	// Labels 写入镜像的 label，gc 用来识别 dockflow 构建的镜像
+	Labels map[string]string
 }
 
-func Build(path string, tag string) error {
+// Build 构建镜像，构建输出写入 out
+// docker buildx 可用时使用 BuildKit，否则使用 classic builder
+func Build(path string, opt BuildOptions, out io.Writer) error {
 	isExist, err := filesystem.DirExists(path)
 	if err != nil {
 		return err
@@ -80,19 +45,44 @@ func Build(path string, tag string) error {
 		return ErrorBuildPathNotExist
 	}
 
-	tarReader, err := TarBuildContext(path)
+	buildKit := BuildKitAvailable()
+
+	dockerfile := "Dockerfile"
+	var extra map[string][]byte
+	if opt.Dockerfile != nil {
+		dockerfile = InjectedDockerfile
+		content := opt.Dockerfile
+		if !buildKit {
+			// classic builder 不支持 RUN --mount，模板去掉缓存挂载后仍可构建
+			content = StripCacheMounts(content)
+		}
+		extra = map[string][]byte{InjectedDockerfile: content}
+	}
+
+	tarReader, err := TarBuildContext(path, extra)
 	if err != nil {
 		return err
 	}
+	defer tarReader.Close()
+
+	if buildKit {
+		return buildWithBuildKit(tarReader, dockerfile, opt, out)
+	}
+	return buildClassic(tarReader, dockerfile, opt, out)
+}
 
+func buildClassic(buildContext io.Reader, dockerfile string, opt BuildOptions, out io.Writer) error {
 	opts := types.ImageBuildOptions{
-		Tags:       []string{tag},
-		Dockerfile: "Dockerfile",
+		Tags:       []string{opt.Tag},
+		Dockerfile: dockerfile,
 		Remove:     true,
-		// BuildArgs:  args,
+		BuildArgs:  opt.BuildArgs,
+		Platform:   opt.Platform,
+		Labels:     opt.Labels,
+		Version:    types.BuilderV1,
 	}
 
-	resp, err := Client().ImageBuild(Ctx(), tarReader, opts)
+	resp, err := Client().ImageBuild(Ctx(), buildContext, opts)
 	if err != nil {
 		return err
 	}
@@ -108,9 +98,10 @@ func Build(path string, tag string) error {
 		}
 
 		if v, ok := msg["stream"]; ok {
-			fmt.Print(v)
+			fmt.Fprint(out, v)
 		}
 		if v, ok := msg["error"]; ok {
+			fmt.Fprintln(out, v)
 			return fmt.Errorf("%v", v)
 		}
 	}
@@ -0,0 +1,130 @@
+package docker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+var (
+	buildKitOnce sync.Once
+	buildKit     bool
+)
+
+// BuildKitAvailable docker CLI 与 buildx 插件是否可用
+func BuildKitAvailable() bool {
+	buildKitOnce.Do(func() {
+		buildKit = exec.Command("docker", "buildx", "version").Run() == nil
+	})
+	return buildKit
+}
+
+// buildWithBuildKit 使用 default builder（即 daemon 内置的 BuildKit）构建
+// 镜像直接写入 daemon，缓存挂载保存在 daemon 中，跨部署复用
+// 构建上下文通过 stdin 以 tar 传入，build args 通过环境变量传入，值不会出现在进程参数中
+// 子进程只继承 docker CLI 需要的环境变量，build args 不能与之同名，避免覆盖 PATH、DOCKER_HOST 等
+func buildWithBuildKit(buildContext io.Reader, dockerfile string, opt BuildOptions, out io.Writer) error {
+	args := []string{
+		"buildx", "build",
+		"--builder", "default",
+		"--progress", "plain",
+		"--tag", opt.Tag,
+		"--file", dockerfile,
+	}
+	if opt.Platform != "" {
+		args = append(args, "--platform", opt.Platform)
+	}
+	labels := make([]string, 0, len(opt.Labels))
+	for key, value := range opt.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		args = append(args, "--label", label)
+	}
+
+	env := buildxEnv()
+	keys := make([]string, 0, len(opt.BuildArgs))
+	for key := range opt.BuildArgs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if reservedBuildxEnv(key) {
+			return fmt.Errorf("build arg [%s] conflicts with docker CLI environment", key)
+		}
+		args = append(args, "--build-arg", key)
+		// nil 表示使用 daemon 进程的同名环境变量
+		value := opt.BuildArgs[key]
+		if value == nil {
+			if v, ok := os.LookupEnv(key); ok {
+				value = &v
+			}
+		}
+		if value != nil {
+			env = append(env, key+"="+*value)
+		}
+	}
+	args = append(args, "-")
+
+	cmd := exec.CommandContext(Ctx(), "docker", args...)
+	cmd.Env = env
+	cmd.Stdin = buildContext
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("buildkit build failed: %w", err)
+	}
+	return nil
+}
+
+// buildxEnvKeys docker CLI 连接 daemon、读取配置与查找 buildx 插件需要的环境变量
+var buildxEnvKeys = []string{
+	"PATH", "HOME",
+	"DOCKER_HOST", "DOCKER_CONTEXT", "DOCKER_CONFIG", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY",
+}
+
+func buildxEnv() []string {
+	env := []string{}
+	for _, key := range buildxEnvKeys {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
+
+// reservedBuildxEnv docker CLI 与 buildx 会读取的变量，作为 build arg 传入会改变 CLI 的行为
+func reservedBuildxEnv(key string) bool {
+	return key == "PATH" || key == "HOME" || strings.HasPrefix(key, "DOCKER_") || strings.HasPrefix(key, "BUILDX_")
+}
+
+var cacheMountPattern = regexp.MustCompile(`--mount=type=cache\S*[ \t]*(\\\r?\n)?[ \t]*`)
+
+// StripCacheMounts 去掉 RUN --mount=type=cache，用于 classic builder
+func StripCacheMounts(dockerfile []byte) []byte {
+	return cacheMountPattern.ReplaceAll(dockerfile, nil)
+}
+
+// PruneBuildCache 清理未使用的构建缓存（包括缓存挂载）
+// olderThan > 0 时只清理超过该时间未使用的，all 为 false 时只清理悬空的缓存
+func PruneBuildCache(olderThan time.Duration, all bool) (*types.BuildCachePruneReport, error) {
+	args := filters.NewArgs()
+	if olderThan > 0 {
+		args.Add("until", olderThan.String())
+	}
+	return Client().BuildCachePrune(Ctx(), types.BuildCachePruneOptions{
+		All:     all,
+		Filters: args,
+	})
+}
@@ -0,0 +1,129 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripCacheMounts(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "single mount",
+			in:   "RUN --mount=type=cache,id=${CACHE_ID}-maven,target=/root/.m2 mvn package\n",
+			want: "RUN mvn package\n",
+		},
+		{
+			name: "continued mounts",
+			in: "RUN --mount=type=cache,id=${CACHE_ID}-npm,target=/root/.npm \\\n" +
+				"    --mount=type=cache,id=${CACHE_ID}-yarn,target=/usr/local/share/.cache/yarn \\\n" +
+				"    ${INSTALL_CMD}\n",
+			want: "RUN ${INSTALL_CMD}\n",
+		},
+		{
+			name: "mount followed by a continued command",
+			in: "RUN --mount=type=cache,id=${CACHE_ID}-pip,target=/root/.cache/pip \\\n" +
+				"    sh -c \"$BUILD_CMD\" \\\n" +
+				" && echo done\n",
+			want: "RUN sh -c \"$BUILD_CMD\" \\\n && echo done\n",
+		},
+		{
+			name: "crlf line endings",
+			in:   "RUN --mount=type=cache,target=/go/pkg/mod \\\r\n    go mod download\r\n",
+			want: "RUN go mod download\r\n",
+		},
+		{
+			name: "other mount types are kept",
+			in:   "RUN --mount=type=secret,id=npmrc npm ci\n",
+			want: "RUN --mount=type=secret,id=npmrc npm ci\n",
+		},
+		{
+			name: "no mounts",
+			in:   "FROM golang:1.22\nRUN go build ./...\n",
+			want: "FROM golang:1.22\nRUN go build ./...\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(StripCacheMounts([]byte(tt.in))); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 模板去掉缓存挂载后不能残留 --mount=type=cache，classic builder 才能构建
+func TestStripCacheMountsTemplates(t *testing.T) {
+	templates, err := filepath.Glob("../../../build-templates/Dockerfile.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) == 0 {
+		t.Skip("build-templates not found")
+	}
+
+	for _, name := range templates {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stripped := string(StripCacheMounts(data))
+		if strings.Contains(stripped, "--mount=type=cache") {
+			t.Errorf("%s: cache mount left after strip", filepath.Base(name))
+		}
+		for _, line := range strings.Split(stripped, "\n") {
+			if strings.TrimSpace(line) == "RUN" || strings.TrimSpace(line) == "RUN \\" {
+				t.Errorf("%s: empty RUN left after strip", filepath.Base(name))
+			}
+		}
+	}
+}
+
+func TestReservedBuildxEnv(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "PATH", want: true},
+		{key: "HOME", want: true},
+		{key: "DOCKER_HOST", want: true},
+		{key: "BUILDX_BUILDER", want: true},
+		{key: "APP_PORT", want: false},
+		{key: "NPM_TOKEN", want: false},
+		{key: "BUILDKIT_INLINE_CACHE", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := reservedBuildxEnv(tt.key); got != tt.want {
+			t.Errorf("reservedBuildxEnv(%q) = %t, want %t", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestBuildxEnvIsMinimal(t *testing.T) {
+	t.Setenv("DOCKFLOW_TEST_LEAK", "1")
+	t.Setenv("DOCKER_HOST", "unix:///tmp/docker.sock")
+
+	env := buildxEnv()
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		if !reservedBuildxEnv(key) {
+			t.Errorf("unexpected variable %s passed to buildx", key)
+		}
+	}
+	found := false
+	for _, kv := range env {
+		if kv == "DOCKER_HOST=unix:///tmp/docker.sock" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("DOCKER_HOST not passed to buildx")
+	}
+}
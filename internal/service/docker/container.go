@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
@@ -20,6 +21,7 @@ import (
 type ContainerLogOptions struct {
 	Follow bool
 	Tail   string
+	Since  string // 10m / 2h / RFC3339 时间戳
 }
 
 // ListContainers 列出容器
@@ -105,6 +107,7 @@ func ContainerLogs(id string, opts ContainerLogOptions) (io.ReadCloser, error) {
 			ShowStderr: true,
 			Follow:     opts.Follow,
 			Tail:       opts.Tail,
+			Since:      opts.Since,
 		},
 	)
 }
@@ -251,6 +254,23 @@ func (o *ContainerRunOptions) WithLabel(k, v string) {
 	o.Labels[k] = v
 }
 
+// WithHealthcheck 设置容器健康检查（等价 docker run --health-*）
+func (o *ContainerRunOptions) WithHealthcheck(
+	test []string,
+	interval time.Duration,
+	timeout time.Duration,
+	startPeriod time.Duration,
+	retries int,
+) {
+	o.Healthcheck = &container.HealthConfig{
+		Test:        test,
+		Interval:    interval,
+		Timeout:     timeout,
+		StartPeriod: startPeriod,
+		Retries:     retries,
+	}
+}
+
 func (o *ContainerRunOptions) WithRestart(mode container.RestartPolicyMode) {
 	o.RestartPolicy = container.RestartPolicy{Name: mode}
 }
@@ -303,3 +323,28 @@ func RunContainer(opts *ContainerRunOptions) (string, error) {
 
 	return resp.ID, nil
 }
+
+// RenameContainer 重命名容器
+func RenameContainer(id string, name string) error {
+	return Client().ContainerRename(Ctx(), id, name)
+}
+
+// ContainerNetworkIP 获取容器在指定网络中的 IP
+func ContainerNetworkIP(info types.ContainerJSON, networkName string) string {
+	if info.NetworkSettings == nil {
+		return ""
+	}
+	if network, ok := info.NetworkSettings.Networks[networkName]; ok && network != nil {
+		return network.IPAddress
+	}
+	return ""
+}
+
+// HasHealthcheck 容器是否配置了健康检查（包括镜像中的 HEALTHCHECK）
+func HasHealthcheck(info types.ContainerJSON) bool {
+	if info.Config == nil || info.Config.Healthcheck == nil {
+		return false
+	}
+	test := info.Config.Healthcheck.Test
+	return len(test) > 0 && test[0] != "NONE"
+}
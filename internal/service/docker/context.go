@@ -0,0 +1,176 @@
+package docker
+
+import (
+	"archive/tar"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+// defaultExcludes 始终排除，.dockerignore 中可以用 ! 重新包含
+var defaultExcludes = []string{".git"}
+
+// TarBuildContext 流式打包构建上下文，ImageBuild 读取的同时写入，不在内存中缓存整个仓库
+// - 遵循 .dockerignore，默认排除 .git；Dockerfile 与 .dockerignore 始终保留
+// - 符号链接按链接本身打包，不跟随；保留文件权限与修改时间
+// - extra 中的文件追加到根目录
+// 调用方读取完或放弃读取时需要 Close，打包失败时错误从 Read 返回
+func TarBuildContext(dir string, extra map[string][]byte) (io.ReadCloser, error) {
+	excludes, err := readDockerignore(dir)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := patternmatcher.New(excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeBuildContext(pw, dir, pm, extra))
+	}()
+	return pr, nil
+}
+
+func readDockerignore(dir string) ([]string, error) {
+	excludes := append([]string{}, defaultExcludes...)
+
+	file, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		defer file.Close()
+		patterns, err := ignorefile.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+		excludes = append(excludes, patterns...)
+	}
+
+	// 与 docker CLI 一致，构建需要的文件不能被排除
+	return append(excludes, "!Dockerfile", "!.dockerignore"), nil
+}
+
+func writeBuildContext(w io.Writer, dir string, pm *patternmatcher.PatternMatcher, extra map[string][]byte) error {
+	tw := tar.NewWriter(w)
+
+	// 目录 → 匹配结果，子路径依据父目录的结果继续匹配
+	parents := map[string]patternmatcher.MatchInfo{}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		excluded, info, err := pm.MatchesUsingParentResults(rel, parents[path.Dir(rel)])
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			parents[rel] = info
+		}
+		if excluded {
+			// 没有 ! 规则可能重新包含其中的文件时，整个目录直接跳过（node_modules 等）
+			if d.IsDir() && !mayReinclude(pm, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return writeTarEntry(tw, p, rel, d)
+	})
+	if err != nil {
+		return err
+	}
+
+	for name, data := range extra {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// mayReinclude 被排除的目录下是否可能有 ! 规则重新包含的文件，与 docker 的判断方式一致
+func mayReinclude(pm *patternmatcher.PatternMatcher, dir string) bool {
+	for _, pattern := range pm.Patterns() {
+		if pattern.Exclusion() && strings.HasPrefix(pattern.String()+"/", dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTarEntry 写入目录、普通文件或符号链接，其他类型（socket、设备等）跳过
+func writeTarEntry(tw *tar.Writer, p, rel string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		if link, err = os.Readlink(p); err != nil {
+			return err
+		}
+	case info.IsDir(), info.Mode().IsRegular():
+	default:
+		return nil
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = rel
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	// 构建上下文不需要保留宿主机的属主
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	file, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(tw, file)
+	return err
+}
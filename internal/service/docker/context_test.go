@@ -0,0 +1,164 @@
+package docker
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// tarEntries 读取构建上下文中的条目名称，目录以 / 结尾
+func tarEntries(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestTarBuildContextDockerignore(t *testing.T) {
+	files := []string{
+		"Dockerfile",
+		"main.go",
+		".env",
+		".git/config",
+		"node_modules/a/index.js",
+		"dist/app.js",
+		"dist/keep.txt",
+		"docs/readme.md",
+		"docs/api/spec.md",
+		"logs/app.log",
+		"sub/logs/app.log",
+	}
+
+	tests := []struct {
+		name         string
+		dockerignore string
+		extra        map[string][]byte
+		want         []string
+	}{
+		{
+			name: "no dockerignore excludes .git only",
+			want: []string{
+				".env", "Dockerfile", "dist/", "dist/app.js", "dist/keep.txt",
+				"docs/", "docs/api/", "docs/api/spec.md", "docs/readme.md",
+				"logs/", "logs/app.log", "main.go",
+				"node_modules/", "node_modules/a/", "node_modules/a/index.js",
+				"sub/", "sub/logs/", "sub/logs/app.log",
+			},
+		},
+		{
+			name:         "directories, globs and comments",
+			dockerignore: "# deps\nnode_modules\n.env\n**/*.log\ndocs/*\n",
+			want: []string{
+				".dockerignore", "Dockerfile", "dist/", "dist/app.js", "dist/keep.txt",
+				"docs/", "logs/", "main.go", "sub/", "sub/logs/",
+			},
+		},
+		{
+			name:         "exclusion reincludes a file in an excluded directory",
+			dockerignore: "dist\n!dist/keep.txt\n",
+			want: []string{
+				".dockerignore", ".env", "Dockerfile", "dist/keep.txt",
+				"docs/", "docs/api/", "docs/api/spec.md", "docs/readme.md",
+				"logs/", "logs/app.log", "main.go",
+				"node_modules/", "node_modules/a/", "node_modules/a/index.js",
+				"sub/", "sub/logs/", "sub/logs/app.log",
+			},
+		},
+		{
+			name:         "Dockerfile and .dockerignore cannot be excluded",
+			dockerignore: "*\n",
+			want:         []string{".dockerignore", "Dockerfile"},
+		},
+		{
+			name:         ".git can be reincluded",
+			dockerignore: "*\n!.git\n",
+			want:         []string{".dockerignore", ".git/", ".git/config", "Dockerfile"},
+		},
+		{
+			name:         "extra files are appended to the root",
+			dockerignore: "*\n",
+			extra:        map[string][]byte{"Dockerfile.dockflow": []byte("FROM scratch\n")},
+			want:         []string{".dockerignore", "Dockerfile", "Dockerfile.dockflow"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range files {
+				p := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(name), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.dockerignore != "" {
+				if err := os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte(tt.dockerignore), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r, err := TarBuildContext(dir, tt.extra)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			if got := tarEntries(t, r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTarBuildContextSymlink(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/passwd", filepath.Join(dir, "passwd")); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := TarBuildContext(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			t.Fatal("symlink not found in build context")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Name != "passwd" {
+			continue
+		}
+		if header.Typeflag != tar.TypeSymlink || header.Linkname != "/etc/passwd" || header.Size != 0 {
+			t.Errorf("symlink followed: type=%c link=%q size=%d", header.Typeflag, header.Linkname, header.Size)
+		}
+		return
+	}
+}
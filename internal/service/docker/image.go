@@ -1,15 +1,24 @@
 package docker
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/moby/term"
 )
 
+var (
+	ErrImageInUse = errors.New("image is used by a container")
+)
+
 func EnsureImage(image string) error {
 	if imageExists(image) {
 		return nil
@@ -69,3 +78,24 @@ func ImageExists(name string) (bool, error) {
 	// 其他错误（Docker daemon 异常等）
 	return false, err
 }
+
+// ListImagesByLabel 带有全部指定 label 的镜像
+func ListImagesByLabel(labels map[string]string) ([]image.Summary, error) {
+	args := filters.NewArgs()
+	for key, value := range labels {
+		args.Add("label", key+"="+value)
+	}
+	return Client().ImageList(Ctx(), types.ImageListOptions{Filters: args})
+}
+
+// RemoveImage 删除镜像 tag，不强制删除，仍被容器使用时返回 ErrImageInUse
+func RemoveImage(ref string) error {
+	_, err := Client().ImageRemove(Ctx(), ref, types.ImageRemoveOptions{PruneChildren: true})
+	if err == nil || client.IsErrNotFound(err) {
+		return nil
+	}
+	if errdefs.IsConflict(err) {
+		return fmt.Errorf("%w: %s", ErrImageInUse, ref)
+	}
+	return err
+}
@@ -1 +1,79 @@
 package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// MaxBuildLogBytes 每个应用构建日志目录的大小上限，超出后删除最旧的日志
+const MaxBuildLogBytes = 64 * 1024 * 1024
+
+// TraefikAppCfgFile 应用某个部署版本对应的 traefik 动态配置文件
+func TraefikAppCfgFile(app, version string) string {
+	return TraefikCfgDir + "/" + app + "_" + version + ".yaml"
+}
+
+// BuildLogDir 应用构建日志目录
+func BuildLogDir(namespace, app string) string {
+	return filepath.Join(NamespaceDirName, namespace, "logs", "build", app)
+}
+
+// BuildLogFile 应用某个版本的构建日志
+func BuildLogFile(namespace, app, version string) string {
+	return filepath.Join(BuildLogDir(namespace, app), version+".log")
+}
+
+// AppLockFile 应用部署锁，同一应用的部署、回滚、重启互斥
+func AppLockFile(namespace, app string) string {
+	return filepath.Join(NamespaceDirName, namespace, "lock", app+".lock")
+}
+
+// PruneBuildLogs 按修改时间从旧到新删除日志，直到目录总大小不超过 maxBytes
+// keep 指定的文件（正在写入的日志）不会被删除
+func PruneBuildLogs(dir string, maxBytes int64, keep string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	type logFile struct {
+		path string
+		info os.FileInfo
+	}
+
+	var files []logFile
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: filepath.Join(dir, entry.Name()), info: info})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].info.ModTime().Before(files[j].info.ModTime())
+	})
+
+	for _, f := range files {
+		if total <= maxBytes {
+			break
+		}
+		if f.path == keep {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= f.info.Size()
+	}
+	return nil
+}
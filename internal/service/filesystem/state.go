@@ -1 +1,19 @@
 package filesystem
+
+const (
+	// StateDBFile 存在时状态保存在内嵌数据库中，否则使用 yaml / json 文件
+	StateDBFile = BaseDirName + "/state.db"
+
+	// SecretKeyFile 加密 secret 的主机密钥，只有 root 可读
+	SecretKeyFile = CfgDir + "secret.key"
+
+	// SecretDirName 未使用内嵌数据库时 secret 密文的保存目录
+	SecretDirName = BaseDirName + "/secrets"
+
+	// DeployQueueFile daemon 部署队列快照，供 CLI 查看
+	DeployQueueFile = BaseDirName + "/deploy-queue.json"
+
+	// APISocketDir / APISocket daemon 管理 API 的 unix socket
+	APISocketDir = "/run/dockflow"
+	APISocket    = APISocketDir + "/dockflow.sock"
+)
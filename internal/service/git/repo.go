@@ -4,6 +4,7 @@ import (
 	dockflowConfig "dockflow/internal/config"
 	"dockflow/internal/domain"
 	"dockflow/internal/service/filesystem"
+	"dockflow/internal/service/secret"
 	"errors"
 	"fmt"
 	"os"
@@ -32,6 +33,9 @@ var (
 	ErrorCommitHashBlank   = errors.New("commit hash can't be blank")
 	ErrorRepoDestRequired  = errors.New("repo url and dest dir required")
 	ErrorResolveCommitFail = errors.New("failed to resolve commit")
+	ErrorGitAuthFailed     = errors.New("git authentication failed")
+	ErrorRepoNotFound      = errors.New("git repository not found")
+	ErrorRemoteUnreachable = errors.New("git remote unreachable")
 )
 
 /*
@@ -54,13 +58,14 @@ func ResolveCommit(opts GitCloneOptions) (string, error) {
 		URLs: []string{opts.RepoURL},
 	})
 
-	listOpts := &git.ListOptions{}
-	listOpts.Auth = auth(opts)
-
-	refs, err := remote.List(listOpts)
+	authMethod, err := auth(opts)
+	if err != nil {
+		return "", err
+	}
 
+	refs, err := remote.List(&git.ListOptions{Auth: authMethod})
 	if err != nil {
-		panic(err)
+		return "", remoteError(opts.RepoURL, err)
 	}
 
 	// 小工具：按 refname 查 hash
@@ -182,15 +187,20 @@ func EnsureRepo(opts GitCloneOptions) (*git.Repository, error) {
 		return nil, err
 	}
 
+	authMethod, err := auth(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// fetch 最新 refs（不 merge）
 	err = repo.Fetch(&git.FetchOptions{
 		RemoteName: "origin",
-		Auth:       auth(opts),
+		Auth:       authMethod,
 		Force:      true,
 		Tags:       git.AllTags,
 	})
 	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return nil, err
+		return nil, remoteError(opts.RepoURL, err)
 	}
 
 	return repo, nil
@@ -247,34 +257,56 @@ func GetLatestCode(opts GitCloneOptions) (string, error) {
 /* ---------- internal helpers ---------- */
 
 func cloneRepo(opts GitCloneOptions) (*git.Repository, error) {
-	cloneOpts := &git.CloneOptions{
-		URL: opts.RepoURL,
+	authMethod, err := auth(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	cloneOpts.Auth = auth(opts)
-
-	return git.PlainClone(opts.DestDir, false, cloneOpts)
+	repo, err := git.PlainClone(opts.DestDir, false, &git.CloneOptions{
+		URL:  opts.RepoURL,
+		Auth: authMethod,
+	})
+	if err != nil {
+		return nil, remoteError(opts.RepoURL, err)
+	}
+	return repo, nil
 }
 
-func auth(opts GitCloneOptions) transport.AuthMethod {
-	if opts.Token != "" {
-		return &http.BasicAuth{
-			Username: "oauth2",
-			Password: opts.Token,
-		}
-	} else {
+func auth(opts GitCloneOptions) (transport.AuthMethod, error) {
+	token, err := secret.Resolve(opts.Token)
+	if err != nil {
+		return nil, fmt.Errorf("resolve git token: %w", err)
+	}
+	if token == "" {
 		gitInfo, err := domain.NewGitUrl(opts.RepoURL)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("parse repo url [%s]: %w", opts.RepoURL, err)
 		}
 
-		token, err := dockflowConfig.FindGit(gitInfo.Host, gitInfo.Username)
+		token, err = dockflowConfig.FindGit(gitInfo.Host, gitInfo.Username)
 		if err != nil {
-			panic(err)
-		}
-		return &http.BasicAuth{
-			Username: "oauth2",
-			Password: token,
+			return nil, fmt.Errorf("load git token: %w", err)
 		}
 	}
+
+	return &http.BasicAuth{
+		Username: "oauth2",
+		Password: token,
+	}, nil
+}
+
+// remoteError 将 go-git 远程操作错误归类为 ErrorGitAuthFailed / ErrorRepoNotFound / ErrorRemoteUnreachable
+func remoteError(repoURL string, err error) error {
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrInvalidAuthMethod):
+		return fmt.Errorf("%w: %s: %v", ErrorGitAuthFailed, repoURL, err)
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		return fmt.Errorf("%w: %s: %v", ErrorRepoNotFound, repoURL, err)
+	case errors.Is(err, transport.ErrEmptyRemoteRepository):
+		return fmt.Errorf("%w: %s", ErrorResolveCommitFail, err)
+	default:
+		return fmt.Errorf("%w: %s: %v", ErrorRemoteUnreachable, repoURL, err)
+	}
 }
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service/secret"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+//
+// ==========================
+// Links
+// ==========================
+//
+
+// linkEnvs 链接的 redis / database 的连接信息
+// 容器名即为 namespace 网络内的 DNS 名称；密码在这里解密，只注入容器
+func linkEnvs(ns *domain.Namespace, links []domain.AppLink) ([]domain.Env, error) {
+	var envs []domain.Env
+	add := func(link domain.AppLink, key, value string) {
+		envs = append(envs, domain.Env{Key: link.EnvKey(key), Value: value})
+	}
+
+	for _, link := range links {
+		switch link.Kind {
+		case domain.ResourceRedis:
+			redis, found := ns.FindRedis(link.Name)
+			if !found {
+				return nil, fmt.Errorf("linked redis [%s] not found", link.Name)
+			}
+			password, err := secret.Resolve(redis.Password)
+			if err != nil {
+				return nil, fmt.Errorf("linked redis [%s]: %w", link.Name, err)
+			}
+
+			u := url.URL{Scheme: "redis", Host: net.JoinHostPort(redis.Name, domain.RedisPort), Path: "/0"}
+			if password != "" {
+				u.User = url.UserPassword("", password)
+			}
+			add(link, "REDIS_HOST", redis.Name)
+			add(link, "REDIS_PORT", domain.RedisPort)
+			add(link, "REDIS_PASSWORD", password)
+			add(link, "REDIS_URL", u.String())
+
+		case domain.ResourceDatabase:
+			database, found := ns.FindDatabase(link.Name)
+			if !found {
+				return nil, fmt.Errorf("linked database [%s] not found", link.Name)
+			}
+			password, err := secret.Resolve(database.Password)
+			if err != nil {
+				return nil, fmt.Errorf("linked database [%s]: %w", link.Name, err)
+			}
+
+			u := url.URL{
+				Scheme: database.Engine(),
+				User:   url.UserPassword(database.Username, password),
+				Host:   net.JoinHostPort(database.Name, database.Port()),
+				Path:   "/" + database.DbName,
+			}
+			add(link, "DB_HOST", database.Name)
+			add(link, "DB_PORT", database.Port())
+			add(link, "DB_NAME", database.DbName)
+			add(link, "DB_USER", database.Username)
+			add(link, "DB_PASSWORD", password)
+			add(link, "DATABASE_URL", u.String())
+
+		default:
+			return nil, fmt.Errorf("invalid link kind: %s", link.Kind)
+		}
+	}
+	return envs, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service/filesystem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+var (
+	ErrAppBusy = errors.New("app has a deployment in progress")
+)
+
+//
+// ==========================
+// App Lock
+// ==========================
+//
+
+// LockApp 同一应用的部署、回滚、重启串行执行，锁是文件锁，CLI 与 daemon 之间同样互斥
+// 锁已被占用时等待释放；进程退出时锁自动释放
+func LockApp(namespace, app string) (func(), error) {
+	return lockApp(namespace, app, syscall.LOCK_EX)
+}
+
+// TryLockApp 锁已被占用时返回 ErrAppBusy，reconcile、gc 等后台任务用来跳过正在部署的应用
+func TryLockApp(namespace, app string) (func(), error) {
+	unlock, err := lockApp(namespace, app, syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return nil, ErrAppBusy
+	}
+	return unlock, err
+}
+
+func lockApp(namespace, app string, how int) (func(), error) {
+	path := filesystem.AppLockFile(namespace, app)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// RecoverInterrupted 把应用残留的 running 部署记录标记为失败，返回标记的条数
+// 调用方需要持有应用锁：锁空闲说明没有部署在进行，running 记录来自中断的部署
+func RecoverInterrupted(namespace, name string) (int, error) {
+	count := 0
+	_, err := domain.UpdateApp(namespace, name, func(app *domain.AppSpec) error {
+		count = app.InterruptRunningDeployments()
+		return nil
+	})
+	return count, err
+}
+
+// lock 获取应用锁并重新读取状态，等待期间上一个部署可能已修改了部署记录
+func (d *AppDeployer) lock() (func(), error) {
+	unlock, err := LockApp(d.app.Namespace, d.app.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := loadNamespace(d.app.Namespace)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	app, found := ns.FindApp(d.app.Name)
+	if !found {
+		unlock()
+		return nil, fmt.Errorf("app [%s] not exist", d.app.Name)
+	}
+	d.ns = ns
+	*d.app = app
+	return unlock, nil
+}
@@ -0,0 +1,90 @@
+package manifest
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	goDirectivePattern = regexp.MustCompile(`(?m)^go\s+(\d+\.\d+)`)
+	goModulePattern    = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	goMainPattern      = regexp.MustCompile(`(?m)^package\s+main\s*$`)
+)
+
+// Go go.mod 的 go 指令与 main 包
+type Go struct{}
+
+func (Go) Match(dir string) (string, bool) {
+	return firstExists(dir, "go.mod")
+}
+
+func (Go) Parse(dir string) (*Manifest, error) {
+	content, err := readFile(dir, "go.mod")
+	if err != nil {
+		return nil, err
+	}
+
+	goVersion := firstSubmatch(goDirectivePattern, content)
+	if goVersion == "" {
+		goVersion = "1.22"
+	}
+
+	mainPkg := goMainPackage(dir, path.Base(firstSubmatch(goModulePattern, content)))
+
+	m := &Manifest{
+		Stack:    StackGo,
+		Version:  goVersion,
+		BuildCmd: "go build -o /out/app " + mainPkg,
+		Artifact: mainPkg,
+	}
+	m.setArg("GO_VERSION", m.Version)
+	m.setArg("MAIN_PKG", m.Artifact)
+	return m, nil
+}
+
+// goMainPackage 根目录是 main 包时使用根目录
+// 否则取 cmd 下的 main 包，多个时优先与模块同名的，再按名称取第一个
+func goMainPackage(dir, moduleName string) string {
+	if isGoMainPackage(dir) {
+		return "."
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "cmd"))
+	if err != nil {
+		return "."
+	}
+	var candidates []string
+	for _, entry := range entries {
+		if entry.IsDir() && isGoMainPackage(filepath.Join(dir, "cmd", entry.Name())) {
+			candidates = append(candidates, entry.Name())
+		}
+	}
+	if len(candidates) == 0 {
+		return "."
+	}
+	sort.Strings(candidates)
+	for _, name := range candidates {
+		if name == moduleName {
+			return "./cmd/" + name
+		}
+	}
+	return "./cmd/" + candidates[0]
+}
+
+func isGoMainPackage(dir string) bool {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err == nil && goMainPattern.Match(data) {
+			return true
+		}
+	}
+	return false
+}
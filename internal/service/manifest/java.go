@@ -3,126 +3,402 @@ package manifest
 import (
 	"encoding/xml"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
+	"strings"
 )
 
+//
+// ==========================
+// Maven
+// ==========================
+//
+
 type pom struct {
 	XMLName xml.Name `xml:"project"`
 
-	ArtifactId string `xml:"artifactId"`
-	Version    string `xml:"version"`
-	Packaging  string `xml:"packaging"`
+	Parent struct {
+		Version string `xml:"version"`
+	} `xml:"parent"`
 
-	Properties struct {
-		JavaVersion  string `xml:"java.version"`
-		MavenVersion string `xml:"maven.version"`
-		Source       string `xml:"maven.compiler.source"`
-		Target       string `xml:"maven.compiler.target"`
-	} `xml:"properties"`
+	ArtifactId string        `xml:"artifactId"`
+	Version    string        `xml:"version"`
+	Packaging  string        `xml:"packaging"`
+	Modules    []string      `xml:"modules>module"`
+	Properties pomProperties `xml:"properties"`
 
 	Build struct {
 		FinalName string `xml:"finalName"`
+		Plugins   []struct {
+			ArtifactId string `xml:"artifactId"`
+		} `xml:"plugins>plugin"`
 	} `xml:"build"`
 }
 
-func ParseJavaMaven(path string) (map[string]*string, error) {
-	data, err := os.ReadFile(path + "/pom.xml")
+// pomProperties <properties> 下任意名称的属性
+type pomProperties map[string]string
+
+func (p *pomProperties) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*p = pomProperties{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var value string
+			if err := d.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			(*p)[t.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (p pom) isSpringBoot() bool {
+	for _, plugin := range p.Build.Plugins {
+		if plugin.ArtifactId == "spring-boot-maven-plugin" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p pom) packaging() string {
+	if p.Packaging == "" {
+		return "jar"
+	}
+	return p.Packaging
+}
+
+// pomModule 多模块项目中的一个模块，Path 相对仓库根目录
+type pomModule struct {
+	Path string
+	Pom  pom
+}
+
+var mavenWrapperPattern = regexp.MustCompile(`apache-maven-(\d+\.\d+\.\d+)`)
+
+// Maven pom.xml，多模块项目选择可运行的模块构建
+type Maven struct{}
+
+func (Maven) Match(dir string) (string, bool) {
+	return firstExists(dir, "pom.xml")
+}
+
+func (Maven) Parse(dir string) (*Manifest, error) {
+	root, err := readPom(dir, "")
 	if err != nil {
 		return nil, err
 	}
 
-	var pom pom
-	if err := xml.Unmarshal(data, &pom); err != nil {
+	modules, err := pomModules(dir, "", root)
+	if err != nil {
 		return nil, err
 	}
+	target := pickPomModule(append([]pomModule{{Pom: root}}, modules...))
 
-	// 1️⃣ Java Version 解析优先级
-	javaVersion := pom.Properties.JavaVersion
-	if javaVersion == "" {
-		javaVersion = pom.Properties.Target
+	// 子模块继承根 pom 的属性
+	props := map[string]string{}
+	for k, v := range root.Properties {
+		props[k] = v
 	}
-	if javaVersion == "" {
-		javaVersion = pom.Properties.Source
+	for k, v := range target.Pom.Properties {
+		props[k] = v
+	}
+	version := target.Pom.Version
+	if version == "" {
+		version = target.Pom.Parent.Version
+	}
+	props["project.artifactId"] = target.Pom.ArtifactId
+	props["project.version"] = version
+	expand := func(s string) string {
+		return os.Expand(s, func(key string) string { return props[key] })
+	}
+
+	// 1️⃣ Java Version 解析优先级
+	javaVersion := ""
+	for _, key := range []string{"java.version", "maven.compiler.release", "maven.compiler.target", "maven.compiler.source"} {
+		if javaVersion = expand(props[key]); javaVersion != "" {
+			break
+		}
 	}
 	if javaVersion == "" {
 		javaVersion = "8"
 	}
 
-	mavenVersion := pom.Properties.MavenVersion
+	mavenVersion := expand(props["maven.version"])
+	if mavenVersion == "" {
+		wrapper, _ := readFile(dir, ".mvn/wrapper/maven-wrapper.properties")
+		if m := mavenWrapperPattern.FindStringSubmatch(wrapper); m != nil {
+			mavenVersion = m[1]
+		}
+	}
 	if mavenVersion == "" {
 		mavenVersion = "3.9.9"
 	}
 
 	// 2️⃣ 产物名解析优先级
-	targetName := pom.Build.FinalName
+	targetName := expand(target.Pom.Build.FinalName)
 	if targetName == "" {
 		// 默认规则：artifactId-version
-		if pom.Version != "" {
-			targetName = pom.ArtifactId + "-" + pom.Version
-		} else {
-			targetName = pom.ArtifactId
+		targetName = target.Pom.ArtifactId
+		if version != "" {
+			targetName += "-" + expand(version)
 		}
 	}
+	targetName += "." + target.Pom.packaging()
+
+	buildCmd := "mvn -B package -DskipTests"
+	if target.Path != "" {
+		buildCmd += " -pl " + target.Path + " -am"
+	}
 
-	packaging := "jar"
-	if pom.Packaging != "" {
-		packaging = pom.Packaging
+	m := &Manifest{
+		Stack:    StackJava,
+		Version:  javaMajor(javaVersion),
+		BuildCmd: buildCmd,
+		Artifact: path.Join(target.Path, "target", targetName),
 	}
-	targetName += "." + packaging
+	m.setArg("JAVA_VERSION", m.Version)
+	m.setArg("MAVEN_VERSION", mavenVersion)
+	m.setArg("BUILD_CMD", m.BuildCmd)
+	m.setArg("TARGET_PATH", m.Artifact)
+	return m, nil
+}
 
-	return map[string]*string{
-		"JAVA_VERSION":  &javaVersion,
-		"MAVEN_VERSION": &mavenVersion,
-		"TARGET_NAME":   &targetName,
-	}, nil
+func readPom(dir, module string) (pom, error) {
+	data, err := os.ReadFile(filepath.Join(dir, module, "pom.xml"))
+	if err != nil {
+		return pom{}, err
+	}
+	var p pom
+	if err := xml.Unmarshal(data, &p); err != nil {
+		return pom{}, err
+	}
+	return p, nil
 }
 
-type Gradle struct {
-	JAVA_VERSION   string
-	GRADLE_VERSION string
-	TARGET_NAME    string
+// pomModules 递归读取全部子模块
+func pomModules(dir, base string, parent pom) ([]pomModule, error) {
+	var modules []pomModule
+	for _, name := range parent.Modules {
+		modulePath := path.Join(base, strings.TrimSpace(name))
+		p, err := readPom(dir, modulePath)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, pomModule{Path: modulePath, Pom: p})
+
+		children, err := pomModules(dir, modulePath, p)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, children...)
+	}
+	return modules, nil
 }
 
-func ParseJavaGradle(page string) (Gradle, error) {
-	data, err := os.ReadFile(page)
-	if err != nil {
-		return Gradle{}, err
+// pickPomModule spring boot 模块优先，其次最后一个 jar / war 模块（通常依赖前面的模块）
+func pickPomModule(modules []pomModule) pomModule {
+	for _, module := range modules {
+		if module.Pom.isSpringBoot() {
+			return module
+		}
+	}
+	for i := len(modules) - 1; i >= 0; i-- {
+		if p := modules[i].Pom.packaging(); p == "jar" || p == "war" {
+			return modules[i]
+		}
 	}
+	return modules[0]
+}
 
-	content := string(data)
+//
+// ==========================
+// Gradle
+// ==========================
+//
 
-	javaVersion := parseGradleValue(content, "sourceCompatibility")
-	if javaVersion == "" {
-		javaVersion = parseGradleValue(content, "targetCompatibility")
+var (
+	gradleRootNamePattern  = regexp.MustCompile(`rootProject\.name\s*=\s*["']([^"']+)["']`)
+	gradleIncludePattern   = regexp.MustCompile(`(?m)^\s*include\s*\(?([^\n]*)`)
+	gradleQuotedPattern    = regexp.MustCompile(`["']([^"']+)["']`)
+	gradleBootPattern      = regexp.MustCompile(`org\.springframework\.boot`)
+	gradleAppPattern       = regexp.MustCompile(`(?m)(^\s*(id\s*\(?\s*)?["']?application["']?\s*\)?\s*$|apply\s+plugin:\s*["']application["'])`)
+	gradleToolchainPattern = regexp.MustCompile(`(?:JavaLanguageVersion\.of|jvmToolchain)\((\d+)\)`)
+	gradleCompatPattern    = regexp.MustCompile(`(?:source|target)Compatibility\s*=?\s*(?:JavaVersion\.(?:VERSION_)?|["'])?([\d._]+)`)
+	gradleVersionPattern   = regexp.MustCompile(`(?m)^\s*version\s*=\s*["']([^"']+)["']`)
+	gradlePropsVerPattern  = regexp.MustCompile(`(?m)^\s*version\s*[=:]\s*(\S+)`)
+	gradleFileNamePattern  = regexp.MustCompile(`archiveFileName(?:\.set\(|\s*=)\s*["']([^"']+)["']`)
+	gradleBaseNamePattern  = regexp.MustCompile(`archiveBaseName(?:\.set\(|\s*=)\s*["']([^"']+)["']`)
+	gradleArcVerPattern    = regexp.MustCompile(`archiveVersion(?:\.set\(|\s*=)\s*["']([^"']*)["']`)
+)
+
+// gradleProject 一个 Gradle 项目，Path 相对仓库根目录，根项目为空
+type gradleProject struct {
+	Path  string
+	Name  string
+	Build string // build.gradle(.kts) 内容
+}
+
+// Gradle 支持 Groovy 与 Kotlin DSL，多项目构建选择可运行的子项目
+type Gradle struct{}
+
+func (Gradle) Match(dir string) (string, bool) {
+	return firstExists(dir, "build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts")
+}
+
+func (Gradle) Parse(dir string) (*Manifest, error) {
+	settings := ""
+	if file, ok := firstExists(dir, "settings.gradle", "settings.gradle.kts"); ok {
+		content, err := readFile(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		settings = content
 	}
-	if javaVersion == "" {
-		javaVersion = parseGradleToolchain(content)
+
+	rootName := filepath.Base(dir)
+	if m := gradleRootNamePattern.FindStringSubmatch(settings); m != nil {
+		rootName = m[1]
+	}
+
+	root, err := readGradleProject(dir, "", rootName)
+	if err != nil {
+		return nil, err
+	}
+	projects := []gradleProject{root}
+	for _, include := range gradleIncludes(settings) {
+		project, err := readGradleProject(dir, include, path.Base(include))
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	target, boot := pickGradleProject(projects)
+
+	// ---------- java version ----------
+	javaVersion := ""
+	for _, content := range []string{target.Build, root.Build} {
+		if m := gradleToolchainPattern.FindStringSubmatch(content); m != nil {
+			javaVersion = m[1]
+		} else if m := gradleCompatPattern.FindStringSubmatch(content); m != nil {
+			javaVersion = m[1]
+		}
+		if javaVersion != "" {
+			break
+		}
 	}
 	if javaVersion == "" {
-		javaVersion = "8"
+		javaVersion = "17"
 	}
 
-	target := parseGradleValue(content, "rootProject.name")
+	// ---------- artifact ----------
+	// bootJar 与 jar 的默认名称相同：<archiveBaseName>-<archiveVersion>.jar
+	fileName := firstSubmatch(gradleFileNamePattern, target.Build)
+	if fileName == "" {
+		baseName := firstSubmatch(gradleBaseNamePattern, target.Build)
+		if baseName == "" {
+			baseName = target.Name
+		}
+		version, ok := gradleArchiveVersion(target.Build)
+		if !ok {
+			version, _ = gradleArchiveVersion(root.Build)
+		}
+		if version == "" {
+			props, _ := readFile(dir, "gradle.properties")
+			version = firstSubmatch(gradlePropsVerPattern, props)
+		}
+		fileName = baseName
+		if version != "" && version != "unspecified" {
+			fileName += "-" + version
+		}
+		fileName += ".jar"
+	}
+
+	// ---------- build command ----------
+	gradle := "gradle"
+	if exists(dir, "gradlew") {
+		gradle = "./gradlew"
+	}
+	task := "build"
+	if boot {
+		task = "bootJar"
+	}
+	if target.Path != "" {
+		task = ":" + strings.ReplaceAll(target.Path, "/", ":") + ":" + task
+	}
 
-	return Gradle{
-		JAVA_VERSION: javaVersion,
-		TARGET_NAME:  target,
-	}, nil
+	m := &Manifest{
+		Stack:    StackGradle,
+		Version:  javaMajor(javaVersion),
+		BuildCmd: gradle + " --no-daemon " + task + " -x test",
+		Artifact: path.Join(target.Path, "build", "libs", fileName),
+	}
+	m.setArg("JAVA_VERSION", m.Version)
+	m.setArg("BUILD_CMD", m.BuildCmd)
+	m.setArg("TARGET_PATH", m.Artifact)
+	return m, nil
 }
 
-func parseGradleValue(content, key string) string {
-	re := regexp.MustCompile(key + `\s*=?\s*['"]?([\w\.]+)['"]?`)
-	m := re.FindStringSubmatch(content)
-	if len(m) > 1 {
-		return m[1]
+func readGradleProject(dir, projectPath, name string) (gradleProject, error) {
+	project := gradleProject{Path: projectPath, Name: name}
+	file, ok := firstExists(filepath.Join(dir, projectPath), "build.gradle", "build.gradle.kts")
+	if !ok {
+		return project, nil
 	}
-	return ""
+	content, err := readFile(filepath.Join(dir, projectPath), file)
+	if err != nil {
+		return project, err
+	}
+	project.Build = content
+	return project, nil
+}
+
+// gradleIncludes settings 中 include 的项目，:app:api → app/api
+func gradleIncludes(settings string) []string {
+	var includes []string
+	for _, line := range gradleIncludePattern.FindAllStringSubmatch(settings, -1) {
+		for _, m := range gradleQuotedPattern.FindAllStringSubmatch(line[1], -1) {
+			includes = append(includes, strings.ReplaceAll(strings.TrimPrefix(m[1], ":"), ":", "/"))
+		}
+	}
+	return includes
+}
+
+// pickGradleProject spring boot 项目优先，其次 application 插件的项目，否则根项目
+func pickGradleProject(projects []gradleProject) (gradleProject, bool) {
+	for _, project := range projects {
+		if gradleBootPattern.MatchString(project.Build) {
+			return project, true
+		}
+	}
+	for _, project := range projects {
+		if gradleAppPattern.MatchString(project.Build) {
+			return project, false
+		}
+	}
+	return projects[0], false
+}
+
+// gradleArchiveVersion 显式设置的 archiveVersion（可以为空）优先于 version
+func gradleArchiveVersion(build string) (string, bool) {
+	if m := gradleArcVerPattern.FindStringSubmatch(build); m != nil {
+		return m[1], true
+	}
+	if m := gradleVersionPattern.FindStringSubmatch(build); m != nil {
+		return m[1], true
+	}
+	return "", false
 }
 
-func parseGradleToolchain(content string) string {
-	re := regexp.MustCompile(`languageVersion\s*=\s*JavaLanguageVersion\.of\((\d+)\)`)
-	m := re.FindStringSubmatch(content)
-	if len(m) > 1 {
+func firstSubmatch(re *regexp.Regexp, content string) string {
+	if m := re.FindStringSubmatch(content); m != nil {
 		return m[1]
 	}
 	return ""
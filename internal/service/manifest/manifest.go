@@ -0,0 +1,180 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 技术栈，与 build-templates 中的模板名一致
+const (
+	StackJava        = "java" // maven
+	StackGradle      = "gradle"
+	StackGo          = "go"
+	StackNodePage    = "node-page"
+	StackNodeService = "node-service"
+	StackPHP         = "php"
+	StackPython      = "python"
+)
+
+var (
+	ErrStackNotDetected = errors.New("no Dockerfile and no supported stack detected")
+)
+
+// Manifest 构建文件的解析结果
+type Manifest struct {
+	Stack    string
+	File     string // 依据的构建文件
+	Version  string // 语言 / 运行时版本
+	BuildCmd string // 构建命令，为空表示不需要构建
+	Artifact string // 产物路径：jar、静态目录、main 包、WSGI/ASGI 入口或 document root
+
+	// Args 模板的 build args，包含上面的字段与各技术栈额外的参数
+	Args map[string]*string
+}
+
+func (m *Manifest) setArg(key, value string) {
+	if m.Args == nil {
+		m.Args = map[string]*string{}
+	}
+	m.Args[key] = &value
+}
+
+// Parser 一种技术栈的构建文件解析
+type Parser interface {
+	// Match 仓库根目录存在该技术栈的构建文件时返回文件名
+	Match(dir string) (string, bool)
+	Parse(dir string) (*Manifest, error)
+}
+
+// parsers 按顺序匹配
+// 后端项目常带有前端资源的 package.json，因此 Node 最后判断
+var parsers = []Parser{
+	Maven{},
+	Gradle{},
+	Go{},
+	PHP{},
+	Python{},
+	Node{},
+}
+
+// Detect 根据仓库根目录的构建文件判断技术栈并解析模板需要的 build args
+func Detect(dir string) (*Manifest, error) {
+	for _, parser := range parsers {
+		file, ok := parser.Match(dir)
+		if !ok {
+			continue
+		}
+		m, err := parser.Parse(dir)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		m.File = file
+		return m, nil
+	}
+	return nil, ErrStackNotDetected
+}
+
+//
+// ==========================
+// Helpers
+// ==========================
+//
+
+func exists(dir, name string) bool {
+	info, err := os.Stat(filepath.Join(dir, name))
+	return err == nil && !info.IsDir()
+}
+
+func dirExists(dir, name string) bool {
+	info, err := os.Stat(filepath.Join(dir, name))
+	return err == nil && info.IsDir()
+}
+
+// firstExists 返回第一个存在的文件
+func firstExists(dir string, names ...string) (string, bool) {
+	for _, name := range names {
+		if exists(dir, name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func readFile(dir, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	return string(data), err
+}
+
+var versionPattern = regexp.MustCompile(`(\d+)(?:\.(\d+))?`)
+
+// majorMinor 从版本约束中取出第一个 x.y，如 ^8.1.3 → 8.1，>=3.10 → 3.10
+func majorMinor(constraint string) string {
+	m := versionPattern.FindStringSubmatch(constraint)
+	if m == nil || m[2] == "" {
+		return ""
+	}
+	return m[1] + "." + m[2]
+}
+
+// constraintOperatorSpace 运算符与版本号之间的空格，如 ">= 8.0"
+var constraintOperatorSpace = regexp.MustCompile(`([<>=!~^])\s+`)
+
+// highestMajorMinor 约束中最高的下界或 ^ / ~ 版本 x.y，< / <= / != 是排除的上界，不参与比较
+// 如 ^7.4|^8.1 → 8.1，>=8.0 <8.3 → 8.0，7.4 - 8.2 → 7.4
+func highestMajorMinor(constraint string) string {
+	constraint = strings.ReplaceAll(constraint, " - ", " <=")
+	constraint = constraintOperatorSpace.ReplaceAllString(constraint, "$1")
+
+	var versions [][2]int
+	for _, term := range strings.FieldsFunc(constraint, func(r rune) bool {
+		return r == '|' || r == ',' || r == ' '
+	}) {
+		if strings.HasPrefix(term, "<") || strings.HasPrefix(term, "!=") {
+			continue
+		}
+		m := versionPattern.FindStringSubmatch(term)
+		if m == nil || m[2] == "" {
+			continue
+		}
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		versions = append(versions, [2]int{major, minor})
+	}
+	if len(versions) == 0 {
+		return ""
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i][0] != versions[j][0] {
+			return versions[i][0] < versions[j][0]
+		}
+		return versions[i][1] < versions[j][1]
+	})
+	v := versions[len(versions)-1]
+	return fmt.Sprintf("%d.%d", v[0], v[1])
+}
+
+// major 版本约束中的主版本号，如 >=18.17 → 18
+func major(constraint string) string {
+	if m := versionPattern.FindStringSubmatch(constraint); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// javaMajor 1.8 / VERSION_1_8 → 8，17 / VERSION_17 → 17，镜像 tag 只使用主版本号
+func javaMajor(version string) string {
+	version = strings.TrimPrefix(version, "JavaVersion.")
+	version = strings.TrimPrefix(version, "VERSION_")
+	version = strings.ReplaceAll(version, "_", ".")
+	version = strings.TrimPrefix(version, "1.")
+	if idx := strings.Index(version, "."); idx != -1 {
+		version = version[:idx]
+	}
+	return version
+}
@@ -0,0 +1,389 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeRepo 在临时目录中写入仓库文件，key 为相对路径
+func writeRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+type wantManifest struct {
+	stack    string
+	version  string
+	buildCmd string
+	artifact string
+	args     map[string]string
+}
+
+func checkManifest(t *testing.T, m *Manifest, want wantManifest) {
+	t.Helper()
+	if m.Stack != want.stack {
+		t.Errorf("stack = %q, want %q", m.Stack, want.stack)
+	}
+	if m.Version != want.version {
+		t.Errorf("version = %q, want %q", m.Version, want.version)
+	}
+	if want.buildCmd != "" && m.BuildCmd != want.buildCmd {
+		t.Errorf("build cmd = %q, want %q", m.BuildCmd, want.buildCmd)
+	}
+	if m.Artifact != want.artifact {
+		t.Errorf("artifact = %q, want %q", m.Artifact, want.artifact)
+	}
+	for key, value := range want.args {
+		got, ok := m.Args[key]
+		if !ok || got == nil || *got != value {
+			t.Errorf("arg %s = %v, want %q", key, got, value)
+		}
+	}
+}
+
+const springBootPlugin = `<build><plugins><plugin><artifactId>spring-boot-maven-plugin</artifactId></plugin></plugins></build>`
+
+func TestMavenParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  wantManifest
+	}{
+		{
+			name: "single module",
+			files: map[string]string{
+				"pom.xml": `<project>
+  <artifactId>shop</artifactId><version>1.2.0</version>
+  <properties><java.version>17</java.version></properties>
+</project>`,
+			},
+			want: wantManifest{
+				stack:    StackJava,
+				version:  "17",
+				buildCmd: "mvn -B package -DskipTests",
+				artifact: "target/shop-1.2.0.jar",
+				args:     map[string]string{"JAVA_VERSION": "17", "MAVEN_VERSION": "3.9.9"},
+			},
+		},
+		{
+			name: "final name, war packaging and java 1.8",
+			files: map[string]string{
+				"pom.xml": `<project>
+  <artifactId>legacy</artifactId><version>1.0</version><packaging>war</packaging>
+  <properties><maven.compiler.source>1.8</maven.compiler.source></properties>
+  <build><finalName>${project.artifactId}-app</finalName></build>
+</project>`,
+				".mvn/wrapper/maven-wrapper.properties": "distributionUrl=https://repo/apache-maven-3.8.8-bin.zip\n",
+			},
+			want: wantManifest{
+				stack:    StackJava,
+				version:  "8",
+				artifact: "target/legacy-app.war",
+				args:     map[string]string{"MAVEN_VERSION": "3.8.8"},
+			},
+		},
+		{
+			name: "multi module picks the spring boot module",
+			files: map[string]string{
+				"pom.xml": `<project>
+  <artifactId>parent</artifactId><version>2.0.0</version><packaging>pom</packaging>
+  <modules><module>common</module><module>server</module><module>tools</module></modules>
+  <properties><java.version>21</java.version></properties>
+</project>`,
+				"common/pom.xml": `<project><parent><version>2.0.0</version></parent><artifactId>common</artifactId></project>`,
+				"server/pom.xml": `<project><parent><version>2.0.0</version></parent><artifactId>server</artifactId>` + springBootPlugin + `</project>`,
+				"tools/pom.xml":  `<project><parent><version>2.0.0</version></parent><artifactId>tools</artifactId></project>`,
+			},
+			want: wantManifest{
+				stack:    StackJava,
+				version:  "21",
+				buildCmd: "mvn -B package -DskipTests -pl server -am",
+				artifact: "server/target/server-2.0.0.jar",
+			},
+		},
+		{
+			name: "nested modules without spring boot pick the last jar module",
+			files: map[string]string{
+				"pom.xml": `<project>
+  <artifactId>parent</artifactId><version>1.0</version><packaging>pom</packaging>
+  <modules><module>libs</module><module>app</module></modules>
+</project>`,
+				"libs/pom.xml":      `<project><artifactId>libs</artifactId><version>1.0</version><packaging>pom</packaging><modules><module>core</module></modules></project>`,
+				"libs/core/pom.xml": `<project><artifactId>core</artifactId><version>1.0</version></project>`,
+				"app/pom.xml":       `<project><artifactId>app</artifactId><version>1.0</version></project>`,
+			},
+			want: wantManifest{
+				stack:    StackJava,
+				version:  "8",
+				buildCmd: "mvn -B package -DskipTests -pl app -am",
+				artifact: "app/target/app-1.0.jar",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Detect(writeRepo(t, tt.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkManifest(t, m, tt.want)
+		})
+	}
+}
+
+func TestGradleParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  wantManifest
+	}{
+		{
+			name: "single project with application plugin",
+			files: map[string]string{
+				"settings.gradle": `rootProject.name = 'cli'`,
+				"build.gradle": `plugins {
+    id 'application'
+}
+version = '0.3.1'
+sourceCompatibility = JavaVersion.VERSION_11
+`,
+			},
+			want: wantManifest{
+				stack:    StackGradle,
+				version:  "11",
+				buildCmd: "gradle --no-daemon build -x test",
+				artifact: "build/libs/cli-0.3.1.jar",
+			},
+		},
+		{
+			name: "kotlin dsl includes pick the spring boot subproject",
+			files: map[string]string{
+				"gradlew":                 "#!/bin/sh\n",
+				"settings.gradle.kts":     "rootProject.name = \"shop\"\ninclude(\":common\", \":services:api\")\n",
+				"build.gradle.kts":        "allprojects { version = \"1.4.0\" }\n",
+				"common/build.gradle.kts": "plugins { `java-library` }\n",
+				"services/api/build.gradle.kts": `plugins {
+    id("org.springframework.boot") version "3.2.0"
+}
+java { toolchain { languageVersion.set(JavaLanguageVersion.of(21)) } }
+`,
+				"gradle.properties": "version=1.4.0\n",
+			},
+			want: wantManifest{
+				stack:    StackGradle,
+				version:  "21",
+				buildCmd: "./gradlew --no-daemon :services:api:bootJar -x test",
+				artifact: "services/api/build/libs/api-1.4.0.jar",
+			},
+		},
+		{
+			name: "groovy include without parentheses and archive name",
+			files: map[string]string{
+				"settings.gradle": "include 'web'\n",
+				"build.gradle":    "",
+				"web/build.gradle": `apply plugin: 'application'
+jar {
+    archiveBaseName = 'site'
+    archiveVersion = ''
+}
+`,
+			},
+			want: wantManifest{
+				stack:    StackGradle,
+				version:  "17",
+				buildCmd: "gradle --no-daemon :web:build -x test",
+				artifact: "web/build/libs/site.jar",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Detect(writeRepo(t, tt.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkManifest(t, m, tt.want)
+		})
+	}
+}
+
+func TestGradleIncludes(t *testing.T) {
+	settings := `rootProject.name = 'shop'
+include 'a', ':b:c'
+include(":d")
+// include 'commented'
+`
+	got := gradleIncludes(settings)
+	want := []string{"a", "b/c", "d"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestNodeParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  wantManifest
+	}{
+		{
+			name: "angular browser builder output path",
+			files: map[string]string{
+				"package.json":      `{"name":"web","scripts":{"build":"ng build"},"dependencies":{"@angular/core":"^16.0.0"}}`,
+				"package-lock.json": "{}",
+				"angular.json":      `{"projects":{"web":{"architect":{"build":{"builder":"@angular-devkit/build-angular:browser","options":{"outputPath":"dist/web-app"}}}}}}`,
+			},
+			want: wantManifest{
+				stack:    StackNodePage,
+				version:  "20",
+				buildCmd: "npm run build",
+				artifact: "dist/web-app",
+				args:     map[string]string{"INSTALL_CMD": "npm ci", "DIST_DIR": "dist/web-app"},
+			},
+		},
+		{
+			name: "angular application builder outputs to browser",
+			files: map[string]string{
+				"package.json": `{"name":"web","engines":{"node":">=18.17"},"scripts":{"build":"ng build"},"dependencies":{"@angular/core":"^17.0.0"}}`,
+				"angular.json": `{"projects":{"web":{"architect":{"build":{"builder":"@angular-devkit/build-angular:application","options":{"outputPath":"dist/web"}}}}}}`,
+			},
+			want: wantManifest{
+				stack:    StackNodePage,
+				version:  "18",
+				artifact: "dist/web/browser",
+			},
+		},
+		{
+			name: "angular output path object",
+			files: map[string]string{
+				"package.json": `{"name":"web","scripts":{"build":"ng build"},"dependencies":{"@angular/core":"^18.0.0"}}`,
+				"angular.json": `{"projects":{"web":{"architect":{"build":{"builder":"@angular-devkit/build-angular:application","options":{"outputPath":{"base":"out/web"}}}}}}}`,
+			},
+			want: wantManifest{
+				stack:    StackNodePage,
+				version:  "20",
+				artifact: "out/web/browser",
+			},
+		},
+		{
+			name: "angular without angular.json falls back to package name",
+			files: map[string]string{
+				"package.json": `{"name":"admin","scripts":{"build":"ng build"},"dependencies":{"@angular/core":"^15.0.0"}}`,
+			},
+			want: wantManifest{
+				stack:    StackNodePage,
+				version:  "20",
+				artifact: "dist/admin",
+			},
+		},
+		{
+			name: "express service with pnpm",
+			files: map[string]string{
+				"package.json":   `{"packageManager":"pnpm@9.0.0","main":"server.js","dependencies":{"express":"^4.0.0"}}`,
+				"pnpm-lock.yaml": "",
+				".nvmrc":         "v22.1.0\n",
+			},
+			want: wantManifest{
+				stack:    StackNodeService,
+				version:  "22",
+				artifact: "node server.js",
+				args:     map[string]string{"INSTALL_CMD": "pnpm install --frozen-lockfile", "PKG_MANAGER": "pnpm"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Detect(writeRepo(t, tt.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkManifest(t, m, tt.want)
+		})
+	}
+}
+
+func TestHighestMajorMinor(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{constraint: "^7.4|^8.1", want: "8.1"},
+		{constraint: "^7.4 || ^8.0", want: "8.0"},
+		{constraint: ">=8.0 <8.3", want: "8.0"},
+		{constraint: ">=7.4 <9.0", want: "7.4"},
+		{constraint: ">= 8.1, < 8.4", want: "8.1"},
+		{constraint: "7.4 - 8.2", want: "7.4"},
+		{constraint: "~8.2.0", want: "8.2"},
+		{constraint: "^8.0 !=8.3", want: "8.0"},
+		{constraint: "8.*", want: ""},
+		{constraint: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := highestMajorMinor(tt.constraint); got != tt.want {
+			t.Errorf("highestMajorMinor(%q) = %q, want %q", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestDetectOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		stack   string
+		wantErr error
+	}{
+		{
+			name:  "backend with package.json is not node",
+			files: map[string]string{"pom.xml": `<project><artifactId>a</artifactId></project>`, "package.json": `{}`},
+			stack: StackJava,
+		},
+		{
+			name:  "php platform version wins over require",
+			files: map[string]string{"composer.json": `{"require":{"php":">=7.4 <9.0"},"config":{"platform":{"php":"8.1.2"}}}`},
+			stack: StackPHP,
+		},
+		{
+			name:    "nothing detected",
+			files:   map[string]string{"README.md": "hi"},
+			wantErr: ErrStackNotDetected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Detect(writeRepo(t, tt.files))
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Stack != tt.stack {
+				t.Errorf("stack = %q, want %q", m.Stack, tt.stack)
+			}
+		})
+	}
+}
@@ -1 +1,236 @@
 package manifest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type packageJSON struct {
+	Name            string            `json:"name"`
+	Main            string            `json:"main"`
+	PackageManager  string            `json:"packageManager"` // corepack，如 pnpm@9.1.0
+	Scripts         map[string]string `json:"scripts"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+	Engines         struct {
+		Node string `json:"node"`
+	} `json:"engines"`
+}
+
+// nodeServerDeps 依赖这些包时作为服务运行，否则有 build 脚本的视为静态站点
+var nodeServerDeps = []string{
+	"express", "koa", "fastify", "@nestjs/core", "@hapi/hapi", "hapi",
+	"next", "nuxt", "@remix-run/serve", "@sveltejs/adapter-node",
+}
+
+// nodeLockfiles 锁文件 → 包管理器，同时存在时按顺序优先
+var nodeLockfiles = []struct {
+	File    string
+	Manager string
+}{
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"package-lock.json", "npm"},
+	{"npm-shrinkwrap.json", "npm"},
+}
+
+var nextExportPattern = regexp.MustCompile(`output\s*:\s*["']export["']`)
+
+func (p packageJSON) has(dep string) bool {
+	_, ok := p.Dependencies[dep]
+	if !ok {
+		_, ok = p.DevDependencies[dep]
+	}
+	return ok
+}
+
+// Node package.json，区分静态站点（node-page）与服务（node-service）
+type Node struct{}
+
+func (Node) Match(dir string) (string, bool) {
+	return firstExists(dir, "package.json")
+}
+
+func (Node) Parse(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	nodeVersion := major(pkg.Engines.Node)
+	if nodeVersion == "" {
+		if nvmrc, err := readFile(dir, ".nvmrc"); err == nil {
+			nodeVersion = major(nvmrc)
+		}
+	}
+	if nodeVersion == "" {
+		nodeVersion = "20"
+	}
+
+	// ---------- package manager ----------
+	manager, locked := nodePackageManager(dir, pkg)
+	installCmd := map[string]string{
+		"npm":  "npm install",
+		"yarn": "yarn install",
+		"pnpm": "pnpm install",
+	}[manager]
+	if locked {
+		installCmd = map[string]string{
+			"npm":  "npm ci",
+			"yarn": "yarn install --frozen-lockfile",
+			"pnpm": "pnpm install --frozen-lockfile",
+		}[manager]
+	}
+
+	buildCmd := ""
+	if _, ok := pkg.Scripts["build"]; ok {
+		buildCmd = manager + " run build"
+	}
+
+	m := &Manifest{
+		Version:  nodeVersion,
+		BuildCmd: buildCmd,
+	}
+
+	if isNodeService(dir, pkg) {
+		startCmd := manager + " start"
+		if _, ok := pkg.Scripts["start"]; !ok {
+			main := pkg.Main
+			if main == "" {
+				main = "index.js"
+			}
+			startCmd = "node " + main
+		}
+		m.Stack = StackNodeService
+		m.Artifact = startCmd
+		m.setArg("START_CMD", startCmd)
+	} else {
+		m.Stack = StackNodePage
+		m.Artifact = nodeDistDir(dir, pkg)
+		m.setArg("DIST_DIR", m.Artifact)
+	}
+
+	m.setArg("NODE_VERSION", m.Version)
+	m.setArg("PKG_MANAGER", manager)
+	m.setArg("INSTALL_CMD", installCmd)
+	m.setArg("BUILD_CMD", m.BuildCmd)
+	return m, nil
+}
+
+// nodePackageManager packageManager 字段优先，其次锁文件，返回是否有锁文件
+func nodePackageManager(dir string, pkg packageJSON) (string, bool) {
+	manager := ""
+	if name, _, ok := strings.Cut(pkg.PackageManager, "@"); ok {
+		switch name {
+		case "npm", "yarn", "pnpm":
+			manager = name
+		}
+	}
+	for _, lock := range nodeLockfiles {
+		if exists(dir, lock.File) && (manager == "" || manager == lock.Manager) {
+			return lock.Manager, true
+		}
+	}
+	if manager == "" {
+		manager = "npm"
+	}
+	return manager, false
+}
+
+func isNodeService(dir string, pkg packageJSON) bool {
+	// next 静态导出
+	if pkg.has("next") {
+		for _, name := range []string{"next.config.js", "next.config.mjs", "next.config.ts"} {
+			if content, err := readFile(dir, name); err == nil && nextExportPattern.MatchString(content) {
+				return false
+			}
+		}
+	}
+	for _, dep := range nodeServerDeps {
+		if pkg.has(dep) {
+			return true
+		}
+	}
+	if _, ok := pkg.Scripts["build"]; !ok {
+		return true
+	}
+	return false
+}
+
+// nodeDistDir 静态站点的产物目录
+func nodeDistDir(dir string, pkg packageJSON) string {
+	switch {
+	case pkg.has("react-scripts"):
+		return "build"
+	case pkg.has("next"):
+		return "out"
+	case pkg.has("@angular/core"):
+		if out := angularOutputPath(dir); out != "" {
+			return out
+		}
+		if pkg.Name != "" {
+			return "dist/" + pkg.Name
+		}
+	}
+	return "dist"
+}
+
+type angularJSON struct {
+	Projects map[string]struct {
+		Architect struct {
+			Build struct {
+				Builder string `json:"builder"`
+				Options struct {
+					OutputPath json.RawMessage `json:"outputPath"`
+				} `json:"options"`
+			} `json:"build"`
+		} `json:"architect"`
+	} `json:"projects"`
+}
+
+// angularOutputPath angular.json 中第一个项目的输出目录
+// application builder（Angular 17+）把静态文件输出到 browser 子目录
+func angularOutputPath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "angular.json"))
+	if err != nil {
+		return ""
+	}
+	var a angularJSON
+	if err := json.Unmarshal(data, &a); err != nil {
+		return ""
+	}
+
+	names := make([]string, 0, len(a.Projects))
+	for name := range a.Projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		build := a.Projects[name].Architect.Build
+
+		var out string
+		if err := json.Unmarshal(build.Options.OutputPath, &out); err != nil {
+			var obj struct {
+				Base string `json:"base"`
+			}
+			if json.Unmarshal(build.Options.OutputPath, &obj) != nil || obj.Base == "" {
+				continue
+			}
+			return obj.Base + "/browser"
+		}
+		if strings.HasSuffix(build.Builder, ":application") {
+			out += "/browser"
+		}
+		return out
+	}
+	return ""
+}
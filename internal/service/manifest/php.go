@@ -0,0 +1,60 @@
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+type composerJSON struct {
+	Require map[string]string `json:"require"`
+	Config  struct {
+		Platform map[string]string `json:"platform"`
+	} `json:"config"`
+}
+
+// PHP composer.json 的 php 约束
+type PHP struct{}
+
+func (PHP) Match(dir string) (string, bool) {
+	return firstExists(dir, "composer.json")
+}
+
+func (PHP) Parse(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "composer.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var c composerJSON
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	// config.platform.php 是锁定的运行版本，优先使用
+	phpVersion := majorMinor(c.Config.Platform["php"])
+	if phpVersion == "" {
+		phpVersion = highestMajorMinor(c.Require["php"])
+	}
+	if phpVersion == "" {
+		phpVersion = "8.2"
+	}
+
+	docRoot := "."
+	for _, name := range []string{"public", "web", "htdocs"} {
+		if dirExists(dir, name) {
+			docRoot = name
+			break
+		}
+	}
+
+	m := &Manifest{
+		Stack:    StackPHP,
+		Version:  phpVersion,
+		BuildCmd: "composer install --no-dev --optimize-autoloader",
+		Artifact: docRoot,
+	}
+	m.setArg("PHP_VERSION", m.Version)
+	m.setArg("DOC_ROOT", m.Artifact)
+	return m, nil
+}
@@ -0,0 +1,146 @@
+package manifest
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	requiresPythonPattern = regexp.MustCompile(`(?m)^requires-python\s*=\s*["']([^"']+)["']`)
+	poetryPythonPattern   = regexp.MustCompile(`(?m)^python\s*=\s*["']([^"']+)["']`)
+	pipfilePythonPattern  = regexp.MustCompile(`(?m)^python_version\s*=\s*["']([^"']+)["']`)
+	pythonAppPattern      = regexp.MustCompile(`(?m)^(\w+)\s*=\s*(FastAPI|Starlette|Quart|Litestar|Sanic|Flask|Bottle|Falcon\.App|falcon\.App)\(`)
+)
+
+// asgiFrameworks 使用 uvicorn 运行，其余使用 gunicorn
+var asgiFrameworks = map[string]bool{
+	"FastAPI": true, "Starlette": true, "Quart": true, "Litestar": true, "Sanic": true,
+}
+
+// pythonEntryFiles 查找应用对象的文件，按顺序
+var pythonEntryFiles = []string{
+	"main.py", "app.py", "server.py", "wsgi.py", "asgi.py",
+	"app/main.py", "app/__init__.py", "src/main.py", "src/app.py",
+}
+
+// Python 版本与 ASGI / WSGI 入口
+type Python struct{}
+
+func (Python) Match(dir string) (string, bool) {
+	return firstExists(dir, "requirements.txt", "pyproject.toml", "Pipfile")
+}
+
+func (Python) Parse(dir string) (*Manifest, error) {
+	pythonVersion := pythonVersion(dir)
+	if pythonVersion == "" {
+		pythonVersion = "3.12"
+	}
+
+	buildCmd := "pip install ."
+	if exists(dir, "requirements.txt") {
+		buildCmd = "pip install -r requirements.txt"
+	} else if exists(dir, "Pipfile") && !exists(dir, "pyproject.toml") {
+		buildCmd = "pip install pipenv && pipenv install --system --deploy"
+	}
+
+	entry, server := pythonEntrypoint(dir)
+	var startCmd string
+	switch server {
+	case "uvicorn":
+		startCmd = "uvicorn " + entry + " --host 0.0.0.0 --port $PORT"
+	case "gunicorn":
+		startCmd = "gunicorn --bind 0.0.0.0:$PORT " + entry
+	default:
+		startCmd = "python " + entry
+	}
+
+	m := &Manifest{
+		Stack:    StackPython,
+		Version:  pythonVersion,
+		BuildCmd: buildCmd,
+		Artifact: entry,
+	}
+	m.setArg("PYTHON_VERSION", m.Version)
+	m.setArg("BUILD_CMD", m.BuildCmd)
+	m.setArg("SERVER", server)
+	m.setArg("START_CMD", startCmd)
+	return m, nil
+}
+
+func pythonVersion(dir string) string {
+	if content, err := readFile(dir, ".python-version"); err == nil {
+		if v := majorMinor(content); v != "" {
+			return v
+		}
+	}
+	if content, err := readFile(dir, "runtime.txt"); err == nil {
+		if v := majorMinor(content); v != "" {
+			return v
+		}
+	}
+	if content, err := readFile(dir, "pyproject.toml"); err == nil {
+		for _, re := range []*regexp.Regexp{requiresPythonPattern, poetryPythonPattern} {
+			if v := majorMinor(firstSubmatch(re, content)); v != "" {
+				return v
+			}
+		}
+	}
+	if content, err := readFile(dir, "Pipfile"); err == nil {
+		if v := majorMinor(firstSubmatch(pipfilePythonPattern, content)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// pythonEntrypoint 返回入口与运行它的服务器（uvicorn / gunicorn），都没找到时直接运行脚本
+// - django：<project>/wsgi.py 或 asgi.py 中的 application
+// - 其他：常见入口文件中的 FastAPI() / Flask() 等应用对象
+func pythonEntrypoint(dir string) (string, string) {
+	if exists(dir, "manage.py") {
+		if files, _ := filepath.Glob(filepath.Join(dir, "*", "asgi.py")); len(files) > 0 && hasPythonDep(dir, "uvicorn") {
+			return path.Base(filepath.Dir(files[0])) + ".asgi:application", "uvicorn"
+		}
+		if files, _ := filepath.Glob(filepath.Join(dir, "*", "wsgi.py")); len(files) > 0 {
+			return path.Base(filepath.Dir(files[0])) + ".wsgi:application", "gunicorn"
+		}
+	}
+
+	for _, file := range pythonEntryFiles {
+		content, err := readFile(dir, file)
+		if err != nil {
+			continue
+		}
+		m := pythonAppPattern.FindStringSubmatch(content)
+		if m == nil {
+			continue
+		}
+		module := strings.TrimSuffix(strings.TrimSuffix(file, ".py"), "/__init__")
+		module = strings.ReplaceAll(module, "/", ".")
+		server := "gunicorn"
+		if asgiFrameworks[m[2]] {
+			server = "uvicorn"
+		}
+		return module + ":" + m[1], server
+	}
+
+	for _, file := range []string{"main.py", "app.py", "server.py"} {
+		if exists(dir, file) {
+			return file, ""
+		}
+	}
+	return "main.py", ""
+}
+
+func hasPythonDep(dir, name string) bool {
+	for _, file := range []string{"requirements.txt", "pyproject.toml", "Pipfile"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err == nil && strings.Contains(strings.ToLower(string(data)), name) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,14 +4,17 @@ import (
 	"dockflow/internal/domain"
 	"dockflow/internal/service/docker"
 	"dockflow/internal/service/filesystem"
+	"dockflow/internal/service/traefik"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/docker/docker/api/types"
 	"github.com/samber/lo"
 )
 
+var errNotApp = errors.New("container is not a dockflow app")
+
 type MonitorContainer struct {
 	ContainerId       string
 	ContainerInfo     types.ContainerJSON
@@ -27,7 +30,9 @@ func NewMonitorContainer(containerId string) *MonitorContainer {
 
 	err := container.findApp()
 	if err != nil {
-		log.Println("[error]", err)
+		if !errors.Is(err, errNotApp) {
+			log.Println("[error]", err)
+		}
 		return nil
 	}
 	container.TraefikConfigFile = container.getTraefikConfigFile()
@@ -42,17 +47,22 @@ func (m *MonitorContainer) findApp() error {
 
 	labels := containerInfo.Config.Labels
 
-	namespace, exists := labels["dockflow.namespace"]
+	// redis / database 等非应用容器
+	if kind := labels[domain.LabelKind]; kind != "" && kind != string(domain.ResourceApp) {
+		return errNotApp
+	}
+
+	namespace, exists := labels[domain.LabelNamespace]
 	if !exists || namespace == "" {
 		return fmt.Errorf("namespace [%s] not set", namespace)
 	}
 
-	name, exists := labels["dockflow.name"]
+	name, exists := labels[domain.LabelName]
 	if !exists || name == "" {
 		return fmt.Errorf("app name [%s] not set", name)
 	}
 
-	version, exists := labels["dockflow.version"]
+	version, exists := labels[domain.LabelVersion]
 	if !exists || version == "" {
 		return fmt.Errorf("app version [%s] not set", version)
 	}
@@ -91,61 +101,73 @@ func (m *MonitorContainer) findApp() error {
 func (m *MonitorContainer) onStart() {
 	log.Println("[container onStart]", m.ContainerId)
 
-	traefikNetworkIp := ""
-	for networkName, network := range m.ContainerInfo.NetworkSettings.Networks {
-		if networkName == "dockflow-traefik" {
-			traefikNetworkIp = network.IPAddress
-		}
+	// 蓝绿切换中的新容器，或已被替换的旧容器，路由由部署流程负责
+	if !m.isCurrent() {
+		log.Println("[traefik] container is not the current deploy of", m.App.Name, m.Deploy.Version)
+		return
 	}
 
-	if traefikNetworkIp == "" {
-		log.Println("[traefik] container not in dockflow-traefik network")
+	// 配置了健康检查的容器，等到 healthy 再挂路由
+	if docker.HasHealthcheck(m.ContainerInfo) {
+		log.Println("[traefik] wait for container healthy", m.ContainerId)
 		return
 	}
 
-	cfg, err := domain.NewTraefikConfig(m.TraefikConfigFile)
-	if err != nil {
-		log.Println("[traefik]      ", err)
+	m.addRoutes()
+}
+
+func (m *MonitorContainer) onHealthy() {
+	log.Println("[container onHealthy]", m.ContainerId)
+
+	if !m.isCurrent() {
 		return
 	}
 
-	for _, url := range m.App.URLs {
-		rule := url.Host
-		if m.Deploy.Version != "latest" {
-			rule += "/" + m.Deploy.Version
-		}
-		traefikOpt := domain.TraefikServiceOpt{
-			Name:      m.App.Name + "_" + m.Deploy.Version + "_" + url.Port,
-			Rule:      rule,
-			Url:       traefikNetworkIp + ":" + url.Port,
-			EnableTLS: true,
-		}
-		cfg.AddService(traefikOpt)
+	m.addRoutes()
+}
+
+func (m *MonitorContainer) onUnhealthy() {
+	log.Println("[container onUnhealthy]", m.ContainerId)
+
+	if !m.isCurrent() {
+		return
 	}
 
-	cfg.Save()
+	if err := traefik.RemoveAppRoutes(m.App.Name, m.Deploy.Version); err != nil {
+		log.Println("[traefik]      ", err)
+	}
+}
+
+func (m *MonitorContainer) addRoutes() {
+	traefikNetworkIp := docker.ContainerNetworkIP(m.ContainerInfo, traefik.TraefikNetwork)
+	if traefikNetworkIp == "" {
+		log.Println("[traefik] container not in dockflow-traefik network")
+		return
+	}
+
+	if err := traefik.WriteAppRoutes(m.App, m.Deploy.Version, traefikNetworkIp); err != nil {
+		log.Println("[traefik]      ", err)
+	}
 }
 
 func (m *MonitorContainer) onDie() {
 	log.Println("[container onDie]", m.ContainerId)
-	os.Remove(m.TraefikConfigFile)
-	// cfg, err := domain.NewTraefikConfig(m.TraefikConfigFile)
-	// if err != nil {
-	// 	log.Println("[traefik]      ", err)
-	// 	return
-	// }
-
-	// for _, url := range m.App.URLs {
-	// 	cfg.RemoveService(m.App.Name + "_" + m.Deploy.Version + "_" + url.Port)
-	// }
-
-	// cfg.Save()
-
-	// if len(cfg.HTTP.Routers) == 0 && len(cfg.HTTP.Services) == 0 {
-	// 	_ = os.Remove(m.TraefikConfigFile)
-	// }
+
+	// 旧容器下线时不能删除已经指向新容器的路由
+	if !m.isCurrent() {
+		return
+	}
+
+	if err := traefik.RemoveAppRoutes(m.App.Name, m.Deploy.Version); err != nil {
+		log.Println("[traefik]      ", err)
+	}
+}
+
+// isCurrent 容器是否为当前记录在 namespace 中的部署
+func (m *MonitorContainer) isCurrent() bool {
+	return m.Deploy.ContainerId == m.ContainerId
 }
 
 func (m *MonitorContainer) getTraefikConfigFile() string {
-	return filesystem.TraefikCfgDir + "/" + m.App.Name + "_" + m.Deploy.Version + ".yaml"
+	return filesystem.TraefikAppCfgFile(m.App.Name, m.Deploy.Version)
 }
@@ -64,9 +64,13 @@ func handleContainerEvent(msg events.Message) {
 	}
 
 	switch action {
-	case "start":
+	case events.ActionStart:
 		containerMonitor.onStart()
-	case "die":
+	case events.ActionDie:
 		containerMonitor.onDie()
+	case events.ActionHealthStatusHealthy:
+		containerMonitor.onHealthy()
+	case events.ActionHealthStatusUnhealthy:
+		containerMonitor.onUnhealthy()
 	}
 }
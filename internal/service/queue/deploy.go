@@ -0,0 +1,250 @@
+package queue
+
+import (
+	"context"
+	"dockflow/internal/service"
+	"dockflow/internal/service/filesystem"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency 未配置时同时执行的部署数量
+const DefaultConcurrency = 2
+
+type JobType string
+
+const (
+	JobDeploy   JobType = "deploy"
+	JobRollback JobType = "rollback"
+)
+
+type JobState string
+
+const (
+	JobQueued  JobState = "queued"
+	JobRunning JobState = "running"
+)
+
+var ErrQueueStopped = errors.New("deploy queue stopped")
+
+type Job struct {
+	Namespace string `json:"namespace"`
+	App       string `json:"app"`
+	Branch    string `json:"branch,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	Trigger   string `json:"trigger"`
+
+	Type       JobType `json:"type,omitempty"`       // 为空时为 deploy
+	RollbackTo string  `json:"rollbackTo,omitempty"` // rollback 的目标版本，为空时回滚到上一个版本
+
+	State      JobState   `json:"state"`
+	EnqueuedAt time.Time  `json:"enqueuedAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	Collapsed  int        `json:"collapsed"` // 被合并掉的旧推送数量
+}
+
+func (j Job) key() string {
+	return j.Namespace + "/" + j.App
+}
+
+// pendingKey 排队中的同一应用同类任务只保留最新一次，部署不会合并掉回滚
+func (j Job) pendingKey() string {
+	if j.Type == "" {
+		return j.key() + "#" + string(JobDeploy)
+	}
+	return j.key() + "#" + string(j.Type)
+}
+
+// Runner 执行一次部署
+type Runner func(job Job) error
+
+// DeployQueue daemon 内的部署队列
+// - 同一个应用同时只执行一个部署
+// - 排队中的同一应用推送只保留最新一次（部署与回滚分别合并）
+// - 全局并发数受 concurrency 限制
+// CLI 直接执行的部署、回滚、重启与队列中的部署通过应用锁（service.LockApp）互斥
+type DeployQueue struct {
+	mu          sync.Mutex
+	concurrency int
+	active      int
+	stopped     bool
+
+	pending map[string]*Job
+	order   []string // pending 的先后顺序
+	running map[string]*Job
+
+	run Runner
+}
+
+func NewDeployQueue(concurrency int, run Runner) *DeployQueue {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &DeployQueue{
+		concurrency: concurrency,
+		pending:     map[string]*Job{},
+		running:     map[string]*Job{},
+		run:         run,
+	}
+}
+
+// Start ctx 结束后不再调度新的部署，正在执行的部署会继续完成
+func (q *DeployQueue) Start(ctx context.Context) {
+	q.mu.Lock()
+	q.saveLocked()
+	q.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		q.mu.Lock()
+		q.stopped = true
+		q.mu.Unlock()
+		log.Println("[queue] deploy queue stopped")
+	}()
+}
+
+// Enqueue 加入队列，同一应用已在排队时替换为新的推送
+func (q *DeployQueue) Enqueue(job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return ErrQueueStopped
+	}
+
+	job.State = JobQueued
+	job.EnqueuedAt = time.Now()
+	job.StartedAt = nil
+
+	key := job.pendingKey()
+	if old, ok := q.pending[key]; ok {
+		job.Collapsed = old.Collapsed + 1
+		log.Printf("[queue] app [%s] collapse queued deploy commit=%s -> commit=%s", key, old.Commit, job.Commit)
+	} else {
+		q.order = append(q.order, key)
+	}
+	q.pending[key] = &job
+
+	q.scheduleLocked()
+	q.saveLocked()
+	return nil
+}
+
+// List 当前运行中和排队中的部署
+func (q *DeployQueue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.snapshotLocked()
+}
+
+func (q *DeployQueue) scheduleLocked() {
+	if q.stopped {
+		return
+	}
+
+	remain := q.order[:0]
+	for _, key := range q.order {
+		job := q.pending[key]
+		if q.active >= q.concurrency || q.running[job.key()] != nil {
+			remain = append(remain, key)
+			continue
+		}
+
+		delete(q.pending, key)
+
+		now := time.Now()
+		job.State = JobRunning
+		job.StartedAt = &now
+		q.running[job.key()] = job
+		q.active++
+
+		go q.execute(*job)
+	}
+	q.order = remain
+}
+
+func (q *DeployQueue) execute(job Job) {
+	log.Printf("[queue] deploy start app [%s] commit=%s", job.key(), job.Commit)
+
+	err := q.runSafe(job)
+	if err != nil {
+		log.Printf("[queue][error] deploy app [%s] failed: %v", job.key(), err)
+	} else {
+		log.Printf("[queue] deploy finished app [%s]", job.key())
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.running, job.key())
+	q.active--
+	q.scheduleLocked()
+	q.saveLocked()
+}
+
+// runSafe 部署 panic 时转换为错误，不影响其他应用的部署
+func (q *DeployQueue) runSafe(job Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("[queue][panic] app [%s]: %v\n%s", job.key(), rec, debug.Stack())
+			err = fmt.Errorf("%w: %v", service.ErrDeployPanic, rec)
+		}
+	}()
+	return q.run(job)
+}
+
+func (q *DeployQueue) snapshotLocked() []Job {
+	jobs := make([]Job, 0, len(q.running)+len(q.pending))
+	for _, job := range q.running {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(*jobs[j].StartedAt)
+	})
+	for _, key := range q.order {
+		jobs = append(jobs, *q.pending[key])
+	}
+	return jobs
+}
+
+// saveLocked 写出队列快照，CLI 通过快照查看队列
+func (q *DeployQueue) saveLocked() {
+	data, err := json.MarshalIndent(q.snapshotLocked(), "", "  ")
+	if err != nil {
+		log.Println("[queue][error]", err)
+		return
+	}
+
+	tmp := filesystem.DeployQueueFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Println("[queue][error]", err)
+		return
+	}
+	if err := os.Rename(tmp, filesystem.DeployQueueFile); err != nil {
+		log.Println("[queue][error]", err)
+	}
+}
+
+// LoadSnapshot 读取 daemon 写出的队列快照
+func LoadSnapshot() ([]Job, error) {
+	data, err := os.ReadFile(filesystem.DeployQueueFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Job{}, nil
+		}
+		return nil, err
+	}
+
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
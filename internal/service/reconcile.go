@@ -0,0 +1,62 @@
+package service
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service/docker"
+	"dockflow/internal/service/traefik"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoImageRecorded = errors.New("deploy record has no image")
+)
+
+//
+// ==========================
+// Recreate
+// ==========================
+//
+
+// Recreate 使用记录的镜像重新创建缺失的版本容器，不拉代码、不构建
+// 返回新容器 ID；容器已创建但未就绪时同样返回 ID
+func (d *AppDeployer) Recreate(deploy domain.AppDeploy) (string, error) {
+	if deploy.Image == "" {
+		return "", ErrNoImageRecorded
+	}
+
+	exists, err := docker.ImageExists(deploy.Image)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("image [%s] not found", deploy.Image)
+	}
+
+	containerName := fmt.Sprintf("%s_%s", d.app.Name, deploy.Version)
+	if err := removeContainerByName(containerName); err != nil {
+		return "", err
+	}
+
+	containerId, err := d.runApp(deploy.Image, deploy.Version, containerName)
+	if err != nil {
+		return "", err
+	}
+
+	err = d.updateApp(func(app *domain.AppSpec) {
+		for i := range app.Deploy {
+			if app.Deploy[i].Version == deploy.Version {
+				app.Deploy[i].ContainerId = containerId
+			}
+		}
+	})
+	if err != nil {
+		return containerId, err
+	}
+
+	ip, err := waitReady(containerId, appPorts(d.app.URLs), readyTimeout(d.app))
+	if err != nil {
+		return containerId, err
+	}
+	return containerId, traefik.WriteAppRoutes(*d.app, deploy.Version, ip)
+}
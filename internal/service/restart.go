@@ -0,0 +1,51 @@
+package service
+
+import (
+	"dockflow/internal/domain"
+	"errors"
+	"log"
+)
+
+var (
+	ErrNoLatest = errors.New("app has no running latest version")
+)
+
+//
+// ==========================
+// Restart
+// ==========================
+//
+
+// Restart 使用 latest 当前的镜像和最新的配置（env / cpu / memory / url）零停机重建 latest
+// 不拉代码、不构建，返回重启的版本
+func (d *AppDeployer) Restart() (version string, err error) {
+	unlock, err := d.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	latest, ok := d.app.Latest()
+	if !ok || latest.Image == "" {
+		return "", ErrNoLatest
+	}
+	version = latest.ImageVersion()
+
+	record := domain.NewDeployment(domain.DeploymentTypeRestart, domain.DeployTriggerCLI)
+	record.Version = version
+	if err := d.saveDeployment(record); err != nil {
+		return version, err
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = panicError(rec)
+		}
+		record.Finish(err)
+		if saveErr := d.saveDeployment(record); saveErr != nil {
+			log.Println("[restart] save deployment record failed", saveErr)
+		}
+	}()
+
+	// 保留回滚来源，重启不改变 latest 的版本
+	return version, d.cutoverLatest(latest.Image, latest.RollbackFrom)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service/docker"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+var (
+	ErrNoRollbackTarget = errors.New("no previous version to rollback to")
+)
+
+//
+// ==========================
+// Rollback
+// ==========================
+//
+
+// Rollback 使用已有镜像重新运行 latest，不拉代码、不构建
+// version 为空时回滚到当前版本的上一个成功版本
+func (d *AppDeployer) Rollback(version string, trigger domain.DeployTrigger) (from string, to string, err error) {
+	unlock, err := d.lock()
+	if err != nil {
+		return "", "", err
+	}
+	defer unlock()
+
+	from = d.CurrentVersion()
+
+	record := domain.NewDeployment(domain.DeploymentTypeRollback, trigger)
+	record.RollbackFrom = from
+	if err := d.saveDeployment(record); err != nil {
+		return from, "", err
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = panicError(rec)
+		}
+		record.Version = to
+		record.Finish(err)
+		if saveErr := d.saveDeployment(record); saveErr != nil {
+			log.Println("[rollback] save deployment record failed", saveErr)
+		}
+	}()
+
+	if version == "" {
+		version, err = d.previousVersion(from)
+		if err != nil {
+			return from, "", err
+		}
+	}
+	to = version
+	if version == "latest" {
+		return from, "", fmt.Errorf("invalid rollback version: %s", version)
+	}
+	if version == from {
+		return from, version, fmt.Errorf("version [%s] is already running", version)
+	}
+
+	image := fmt.Sprintf("%s:%s", d.app.Name, version)
+	exists, err := docker.ImageExists(image)
+	if err != nil {
+		return from, version, err
+	}
+	if !exists {
+		return from, version, fmt.Errorf("image [%s] not found", image)
+	}
+
+	if err := d.cutoverLatest(image, from); err != nil {
+		return from, version, err
+	}
+	return from, version, nil
+}
+
+// CurrentVersion latest 当前运行的版本
+func (d *AppDeployer) CurrentVersion() string {
+	for _, deploy := range d.app.Deploy {
+		if deploy.Version == "latest" {
+			return deploy.ImageVersion()
+		}
+	}
+	return ""
+}
+
+// versionHistory 按部署时间排列的版本记录（不含 latest）
+func (d *AppDeployer) versionHistory() []domain.AppDeploy {
+	var versions []domain.AppDeploy
+	for _, deploy := range d.app.Deploy {
+		if deploy.Version != "latest" {
+			versions = append(versions, deploy)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].DeployedAt.Before(versions[j].DeployedAt)
+	})
+	return versions
+}
+
+// previousVersion 当前版本之前、镜像仍然存在的最近一个版本
+func (d *AppDeployer) previousVersion(current string) (string, error) {
+	versions := d.versionHistory()
+
+	end := len(versions)
+	for i, v := range versions {
+		if v.Version == current {
+			end = i
+			break
+		}
+	}
+	// 当前版本未知时，latest 视为最后一个版本
+	if end == len(versions) {
+		end = len(versions) - 1
+	}
+
+	for i := end - 1; i >= 0; i-- {
+		exists, err := docker.ImageExists(fmt.Sprintf("%s:%s", d.app.Name, versions[i].Version))
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return versions[i].Version, nil
+		}
+	}
+	return "", ErrNoRollbackTarget
+}
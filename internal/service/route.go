@@ -0,0 +1,46 @@
+package service
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service/docker"
+	"dockflow/internal/service/traefik"
+	"log"
+)
+
+//
+// ==========================
+// Routes
+// ==========================
+//
+
+// RewriteRoutes 按 urls 重写各个已部署版本的路由，容器不重启
+// 用于删除 url：删除的 host 立即不再路由，其余 url 保持部署时的端口，新的配置在重新部署或重启后生效
+// 容器不存在或没有 IP 的版本跳过，由 reconcile 重建时写入
+func (d *AppDeployer) RewriteRoutes(urls []domain.AppURL) error {
+	unlock, err := d.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	app := *d.app
+	app.URLs = urls
+	for _, deploy := range d.app.Deploy {
+		if deploy.ContainerId == "" {
+			continue
+		}
+		info, err := docker.InspectContainer(deploy.ContainerId)
+		if err != nil {
+			log.Println("[routes] inspect container failed", app.Name, deploy.Version, err)
+			continue
+		}
+		ip := docker.ContainerNetworkIP(info, traefik.TraefikNetwork)
+		if ip == "" {
+			continue
+		}
+		if err := traefik.WriteAppRoutes(app, deploy.Version, ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package secret
+
+import (
+	"crypto/rand"
+	"dockflow/internal/service/filesystem"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keySize AES-256
+const keySize = 32
+
+var (
+	ErrNoHostKey      = errors.New("secret host key not found, create a secret first or restore " + filesystem.SecretKeyFile)
+	ErrInvalidHostKey = errors.New("invalid secret host key")
+)
+
+// loadKey 读取主机密钥，create 为 true 且不存在时生成
+// 密钥以 hex 保存，文件权限 0600；并发创建时以先创建的为准
+func loadKey(create bool) ([]byte, error) {
+	data, err := os.ReadFile(filesystem.SecretKeyFile)
+	if err == nil {
+		return decodeKey(data)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if !create {
+		return nil, ErrNoHostKey
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	if err := writeKeyFile(filesystem.SecretKeyFile, key); err != nil {
+		if os.IsExist(err) {
+			// 另一个进程先创建了密钥，以它为准
+			return loadKey(false)
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// writeKeyFile 先写入同目录的临时文件并 fsync，再 link 到目标路径
+// link 是原子的，其他进程读到的要么是不存在，要么是完整的密钥；目标已存在时返回 EEXIST
+func writeKeyFile(path string, key []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := tmp.Chmod(0600); err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		return fmt.Errorf("write secret host key: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Link(tmp.Name(), path)
+}
+
+func decodeKey(data []byte) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != keySize {
+		return nil, ErrInvalidHostKey
+	}
+	return key, nil
+}
@@ -0,0 +1,256 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"dockflow/internal/state"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RefPrefix 引用 secret 的值，如 secret://shop.web.token
+const RefPrefix = "secret://"
+
+var (
+	ErrSecretNotFound    = errors.New("secret not found")
+	ErrInvalidSecretName = errors.New("invalid secret name, use letters, digits, '.', '_' and '-'")
+	ErrDecrypt           = errors.New("decrypt secret failed, host key does not match")
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// record 保存到 state 的内容，只包含密文
+type record struct {
+	Name       string    `json:"name"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// Info secret 的元数据，不包含值
+type Info struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+//
+// ==========================
+// Reference
+// ==========================
+//
+
+func Ref(name string) string {
+	return RefPrefix + name
+}
+
+// ParseRef 值为 secret 引用时返回 secret 名称
+func ParseRef(value string) (string, bool) {
+	if !strings.HasPrefix(value, RefPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, RefPrefix), true
+}
+
+func IsRef(value string) bool {
+	_, ok := ParseRef(value)
+	return ok
+}
+
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// SanitizeName 将任意字符串转换为合法的 secret 名称片段
+func SanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '-'
+	}, s)
+}
+
+// Resolve 解析配置中的值：secret 引用解密为明文，其他值原样返回
+// 只在注入容器、调用 git 平台等需要明文的地方调用
+func Resolve(value string) (string, error) {
+	name, ok := ParseRef(value)
+	if !ok {
+		return value, nil
+	}
+	return Decrypt(name)
+}
+
+// Matches 保存的值（可能是引用）与明文是否一致，用于判断配置是否变化
+func Matches(stored, plain string) (bool, error) {
+	if stored == plain {
+		return true, nil
+	}
+	if !IsRef(stored) || IsRef(plain) {
+		return false, nil
+	}
+	value, err := Resolve(stored)
+	if err != nil {
+		return false, err
+	}
+	return value == plain, nil
+}
+
+//
+// ==========================
+// Store
+// ==========================
+//
+
+// Set 加密保存，已存在时覆盖，返回引用
+func Set(name, value string) (string, error) {
+	if !ValidName(name) {
+		return "", ErrInvalidSecretName
+	}
+
+	key, err := loadKey(true)
+	if err != nil {
+		return "", err
+	}
+
+	err = state.Default().Update(state.KindSecret, name, func(old []byte) ([]byte, error) {
+		now := time.Now()
+		rec := record{Name: name, CreatedAt: now}
+		if old != nil {
+			if err := json.Unmarshal(old, &rec); err != nil {
+				return nil, err
+			}
+		}
+		rec.UpdatedAt = now
+
+		rec.Nonce, rec.Ciphertext, err = seal(key, name, []byte(value))
+		if err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(rec, "", "  ")
+	})
+	if err != nil {
+		return "", err
+	}
+	return Ref(name), nil
+}
+
+// Decrypt 解密 secret
+func Decrypt(name string) (string, error) {
+	rec, err := get(name)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := loadKey(false)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := open(key, rec)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func Exists(name string) (bool, error) {
+	_, err := get(name)
+	if errors.Is(err, ErrSecretNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List 按名称排序
+func List() ([]Info, error) {
+	names, err := state.Default().List(state.KindSecret)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	infos := make([]Info, 0, len(names))
+	for _, name := range names {
+		rec, err := get(name)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, Info{Name: rec.Name, CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt})
+	}
+	return infos, nil
+}
+
+func Delete(name string) error {
+	return state.Default().Delete(state.KindSecret, name)
+}
+
+func get(name string) (*record, error) {
+	data, err := state.Default().Get(state.KindSecret, name)
+	if err != nil {
+		if errors.Is(err, state.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+		}
+		return nil, err
+	}
+
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("decode secret %s: %w", name, err)
+	}
+	if rec.Name == "" {
+		rec.Name = name
+	}
+	return &rec, nil
+}
+
+//
+// ==========================
+// AES-GCM
+// ==========================
+//
+
+// seal 名称作为附加数据，密文不能被复制到其他 secret 名下使用
+func seal(key []byte, name string, plain []byte) ([]byte, []byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plain, []byte(name)), nil
+}
+
+func open(key []byte, rec *record) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rec.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: %s", ErrDecrypt, rec.Name)
+	}
+	plain, err := gcm.Open(nil, rec.Nonce, rec.Ciphertext, []byte(rec.Name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecrypt, rec.Name)
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
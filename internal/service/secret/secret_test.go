@@ -0,0 +1,209 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, keySize)
+}
+
+func TestSealOpen(t *testing.T) {
+	key := testKey(1)
+
+	tests := []struct {
+		name    string
+		tamper  func(rec *record)
+		openKey []byte
+		wantErr error
+	}{
+		{
+			name: "round trip",
+		},
+		{
+			name:    "wrong key",
+			openKey: testKey(2),
+			wantErr: ErrDecrypt,
+		},
+		{
+			name:    "copied to another name",
+			tamper:  func(rec *record) { rec.Name = "other" },
+			wantErr: ErrDecrypt,
+		},
+		{
+			name:    "modified ciphertext",
+			tamper:  func(rec *record) { rec.Ciphertext[0] ^= 0xff },
+			wantErr: ErrDecrypt,
+		},
+		{
+			name:    "invalid nonce",
+			tamper:  func(rec *record) { rec.Nonce = rec.Nonce[:4] },
+			wantErr: ErrDecrypt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain := []byte("s3cr3t value")
+			nonce, ciphertext, err := seal(key, "shop.web.token", plain)
+			if err != nil {
+				t.Fatalf("seal: %v", err)
+			}
+			if bytes.Contains(ciphertext, plain) {
+				t.Fatal("ciphertext contains the plain text")
+			}
+
+			rec := &record{Name: "shop.web.token", Nonce: nonce, Ciphertext: ciphertext}
+			if tt.tamper != nil {
+				tt.tamper(rec)
+			}
+			openKey := key
+			if tt.openKey != nil {
+				openKey = tt.openKey
+			}
+
+			got, err := open(openKey, rec)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			if !bytes.Equal(got, plain) {
+				t.Errorf("got %q, want %q", got, plain)
+			}
+		})
+	}
+}
+
+func TestSealUsesFreshNonce(t *testing.T) {
+	key := testKey(1)
+	nonce1, ciphertext1, err := seal(key, "a", []byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce2, ciphertext2, err := seal(key, "a", []byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(nonce1, nonce2) || bytes.Equal(ciphertext1, ciphertext2) {
+		t.Error("sealing the same value twice produced the same nonce or ciphertext")
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "hex with newline", data: "0101010101010101010101010101010101010101010101010101010101010101\n"},
+		{name: "not hex", data: "zz", wantErr: true},
+		{name: "too short", data: "0101", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := decodeKey([]byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidHostKey) {
+					t.Fatalf("got error %v, want %v", err, ErrInvalidHostKey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(key, testKey(1)) {
+				t.Errorf("got %x", key)
+			}
+		})
+	}
+}
+
+func TestRef(t *testing.T) {
+	tests := []struct {
+		value  string
+		name   string
+		wantOk bool
+	}{
+		{value: "secret://shop.web.token", name: "shop.web.token", wantOk: true},
+		{value: "plain", wantOk: false},
+		{value: "Secret://x", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		name, ok := ParseRef(tt.value)
+		if ok != tt.wantOk || name != tt.name {
+			t.Errorf("ParseRef(%q) = %q, %t, want %q, %t", tt.value, name, ok, tt.name, tt.wantOk)
+		}
+		if ok && Ref(name) != tt.value {
+			t.Errorf("Ref(%q) = %q, want %q", name, Ref(name), tt.value)
+		}
+	}
+}
+
+func TestMatchesWithoutDecrypt(t *testing.T) {
+	tests := []struct {
+		stored, plain string
+		want          bool
+	}{
+		{stored: "abc", plain: "abc", want: true},
+		{stored: "abc", plain: "abd", want: false},
+		{stored: "secret://a", plain: "secret://a", want: true},
+		{stored: "secret://a", plain: "secret://b", want: false},
+	}
+
+	for _, tt := range tests {
+		got, err := Matches(tt.stored, tt.plain)
+		if err != nil {
+			t.Fatalf("Matches(%q, %q): %v", tt.stored, tt.plain, err)
+		}
+		if got != tt.want {
+			t.Errorf("Matches(%q, %q) = %t, want %t", tt.stored, tt.plain, got, tt.want)
+		}
+	}
+}
+
+func TestWriteKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret", "host.key")
+
+	if err := writeKeyFile(path, testKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	// 已存在时不覆盖，调用方重新读取
+	if err := writeKeyFile(path, testKey(2)); !os.IsExist(err) {
+		t.Fatalf("got error %v, want EEXIST", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := decodeKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, testKey(1)) {
+		t.Error("key file overwritten")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("temp files left: %v", entries)
+	}
+}
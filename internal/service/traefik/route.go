@@ -0,0 +1,47 @@
+package traefik
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service/filesystem"
+	"os"
+)
+
+// WriteAppRoutes 重写应用某个版本的路由配置，全部指向给定的容器 IP
+// latest 使用 url.Host，其他版本使用 url.Host/<version>
+func WriteAppRoutes(app domain.AppSpec, version string, ip string) error {
+	cfg, err := domain.NewTraefikConfig(filesystem.TraefikAppCfgFile(app.Name, version))
+	if err != nil {
+		return err
+	}
+
+	// 整体覆盖，已删除的 url 不再保留
+	cfg.HTTP = domain.HTTPConfig{
+		Routers:     make(map[string]domain.Router),
+		Services:    make(map[string]domain.Service),
+		Middlewares: make(map[string]domain.Middleware),
+	}
+
+	for _, url := range app.URLs {
+		rule := url.Host
+		if version != "latest" {
+			rule += "/" + version
+		}
+		cfg.AddService(domain.TraefikServiceOpt{
+			Name:      app.Name + "_" + version + "_" + url.Port,
+			Rule:      rule,
+			Url:       ip + ":" + url.Port,
+			EnableTLS: true,
+		})
+	}
+
+	return cfg.Save()
+}
+
+// RemoveAppRoutes 删除应用某个版本的路由配置
+func RemoveAppRoutes(appName string, version string) error {
+	err := os.Remove(filesystem.TraefikAppCfgFile(appName, version))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
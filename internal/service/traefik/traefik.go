@@ -4,6 +4,7 @@ import (
 	"dockflow/internal/config"
 	"dockflow/internal/service/docker"
 	"dockflow/internal/service/filesystem"
+	"fmt"
 	"log"
 	"strings"
 
@@ -98,8 +99,7 @@ func ensureNetwork(cfg *config.Config) (err error) {
 			}
 		}
 	}
-	config.Save(cfg)
-	return nil
+	return config.Save(cfg)
 }
 
 func createTraefikNetwork(cfg *config.Config) (string, error) {
@@ -122,52 +122,44 @@ func createTraefikNetwork(cfg *config.Config) (string, error) {
 		return "", err
 	}
 	cfg.Platform.Traefik.NetworkId = networkId
-	config.Save(cfg)
+	if err := config.Save(cfg); err != nil {
+		return "", err
+	}
 
 	return networkId, nil
 }
 
-func ensureContainer(cfg *config.Config) (err error) {
+func ensureContainer(cfg *config.Config) error {
 	containerId := strings.TrimSpace(cfg.Platform.Traefik.ContainerId)
 
-	if containerId == "" {
-		containerId, err = createTraefikContainer()
+	if containerId != "" {
+		id, err := docker.HasContainer(containerId)
 		if err != nil {
-			panic(err)
-			// err = docker.StopContainer(containerId, nil)
-			// if err != nil {
-			// 	panic(err)
-			// }
-			// err = docker.RemoveContainer(containerId, true)
-			// if err != nil {
-			// 	panic(err)
-			// }
+			return fmt.Errorf("inspect traefik container: %w", err)
 		}
+		containerId = id
+	}
 
-		cfg.Platform.Traefik.ContainerId = containerId
-		config.Save(cfg)
-	} else {
-		containerId, err := docker.HasContainer(containerId)
+	// ---------- 不存在则创建 ----------
+	if containerId == "" {
+		id, err := createTraefikContainer()
 		if err != nil {
-			panic(err)
-		}
-		if containerId == "" {
-			containerId, err = createTraefikContainer()
-			cfg.Platform.Traefik.ContainerId = containerId
-			config.Save(cfg)
-		} else {
-			isRun, err := docker.ContainerRunning(containerId)
-			if err != nil {
-				panic(err)
-			}
-			if !isRun {
-				err = docker.StartContainer(containerId)
-				if err != nil {
-					panic(err)
-				}
-			}
+			return fmt.Errorf("create traefik container: %w", err)
 		}
 
+		cfg.Platform.Traefik.ContainerId = id
+		return config.Save(cfg)
+	}
+
+	// ---------- 已存在确保运行 ----------
+	isRun, err := docker.ContainerRunning(containerId)
+	if err != nil {
+		return fmt.Errorf("inspect traefik container: %w", err)
+	}
+	if !isRun {
+		if err := docker.StartContainer(containerId); err != nil {
+			return fmt.Errorf("start traefik container: %w", err)
+		}
 	}
 
 	return nil
@@ -194,10 +186,5 @@ func createTraefikContainer() (containerId string, err error) {
 		"--providers.providersThrottleDuration=2s",
 	)
 
-	containerId, err = docker.RunContainer(opt)
-
-	if err != nil {
-		return containerId, err
-	}
-	return "", err
+	return docker.RunContainer(opt)
 }
@@ -11,7 +11,7 @@ const (
 )
 
 // ---------- GitHub ----------
-func (s *GitService) handleGitHub(ns string, appName string, body []byte) {
+func (s *GitService) handleGitHub(ns string, appName string, body []byte) bool {
 	var p struct {
 		Ref        string `json:"ref"`
 		Repository struct {
@@ -23,7 +23,7 @@ func (s *GitService) handleGitHub(ns string, appName string, body []byte) {
 	}
 
 	if err := json.Unmarshal(body, &p); err != nil {
-		return
+		return false
 	}
 
 	refType, refName := parseGitRef(p.Ref)
@@ -41,11 +41,11 @@ func (s *GitService) handleGitHub(ns string, appName string, body []byte) {
 		Commit: p.HeadCommit.ID,
 	}
 
-	s.Handle(event)
+	return s.Handle(event)
 }
 
 // ---------- GitLab ----------
-func (s *GitService) handleGitLab(ns string, appName string, body []byte) {
+func (s *GitService) handleGitLab(ns string, appName string, body []byte) bool {
 	var p struct {
 		Ref     string `json:"ref"`
 		Project struct {
@@ -55,7 +55,7 @@ func (s *GitService) handleGitLab(ns string, appName string, body []byte) {
 	}
 
 	if err := json.Unmarshal(body, &p); err != nil {
-		return
+		return false
 	}
 
 	refType, refName := parseGitRef(p.Ref)
@@ -63,7 +63,7 @@ func (s *GitService) handleGitLab(ns string, appName string, body []byte) {
 	event := GitPushEvent{
 		Namespace: ns,
 		AppName:   appName,
-		Provider:  "github",
+		Provider:  "gitlab",
 
 		Repo:    p.Project.Path,
 		Ref:     p.Ref,
@@ -73,11 +73,11 @@ func (s *GitService) handleGitLab(ns string, appName string, body []byte) {
 		Commit: p.CheckoutSha,
 	}
 
-	s.Handle(event)
+	return s.Handle(event)
 }
 
 // ---------- Gitee ----------
-func (s *GitService) handleGitee(ns string, appName string, body []byte) {
+func (s *GitService) handleGitee(ns string, appName string, body []byte) bool {
 	var p struct {
 		Ref        string `json:"ref"`
 		Repository struct {
@@ -89,7 +89,7 @@ func (s *GitService) handleGitee(ns string, appName string, body []byte) {
 	}
 
 	if err := json.Unmarshal(body, &p); err != nil {
-		return
+		return false
 	}
 
 	refType, refName := parseGitRef(p.Ref)
@@ -107,7 +107,7 @@ func (s *GitService) handleGitee(ns string, appName string, body []byte) {
 		Commit: p.HeadCommit.ID,
 	}
 
-	s.Handle(event)
+	return s.Handle(event)
 }
 
 // ---------- util ----------
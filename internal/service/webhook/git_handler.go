@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"dockflow/internal/domain"
+	"dockflow/internal/service/secret"
 	"io"
 	"log"
 	"net/http"
@@ -53,11 +54,18 @@ func (s *GitService) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	// 读取应用配置（你现有的方式）
 	provider := detectGitProvider(r.Header)
 
+	webhookSecret, err := secret.Resolve(app.Secret)
+	if err != nil {
+		log.Printf("[webhook][error] app [%s] resolve secret: %v\n", appName, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	// ---------- Webhook 安全校验 ----------
 	switch provider {
 	case "github":
 		if !verifyGitHubSignature(
-			app.Secret,
+			webhookSecret,
 			body,
 			r.Header.Get("X-Hub-Signature-256"),
 		) {
@@ -68,7 +76,7 @@ func (s *GitService) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		}
 
 	case "gitlab":
-		if !verifySimpleToken(r, app.Secret) {
+		if !verifySimpleToken(r, webhookSecret) {
 			w.WriteHeader(http.StatusUnauthorized)
 			w.Write([]byte("invalid gitlab token"))
 			log.Println("[webhook][error] invalid github signature")
@@ -76,7 +84,7 @@ func (s *GitService) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		}
 
 	case "gitee":
-		if !verifySimpleToken(r, app.Secret) {
+		if !verifySimpleToken(r, webhookSecret) {
 			w.WriteHeader(http.StatusUnauthorized)
 			w.Write([]byte("invalid gitee token"))
 			log.Println("[webhook][error] invalid github signature")
@@ -89,18 +97,25 @@ func (s *GitService) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// ---------- 校验通过，进入业务 ----------
+	// ---------- 校验通过，加入部署队列后立即返回 ----------
+	var queued bool
 	switch provider {
 	case "github":
-		s.handleGitHub(nsName, appName, body)
+		queued = s.handleGitHub(nsName, appName, body)
 	case "gitlab":
-		s.handleGitLab(nsName, appName, body)
+		queued = s.handleGitLab(nsName, appName, body)
 	case "gitee":
-		s.handleGitee(nsName, appName, body)
+		queued = s.handleGitee(nsName, appName, body)
+	}
+
+	if !queued {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ignored"))
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("ok"))
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("queued"))
 }
 
 func detectGitProvider(h http.Header) string {
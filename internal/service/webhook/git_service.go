@@ -2,7 +2,7 @@ package webhook
 
 import (
 	"dockflow/internal/domain"
-	"dockflow/internal/usecase"
+	"dockflow/internal/service/queue"
 	"log"
 	"path/filepath"
 	"strings"
@@ -24,10 +24,13 @@ type GitPushEvent struct {
 }
 
 type GitService struct {
+	queue *queue.DeployQueue
 }
 
-func NewGitService() *GitService {
-	return &GitService{}
+func NewGitService(deployQueue *queue.DeployQueue) *GitService {
+	return &GitService{
+		queue: deployQueue,
+	}
 }
 
 func (s *GitService) matchTriggerRule(rule string, refName string) bool {
@@ -49,7 +52,8 @@ func (s *GitService) matchTriggerRule(rule string, refName string) bool {
 	return ok
 }
 
-func (s *GitService) Handle(event GitPushEvent) {
+// Handle 校验触发规则，匹配时加入部署队列，返回是否已入队
+func (s *GitService) Handle(event GitPushEvent) bool {
 	log.Println("[git push]",
 		"namespace=", event.Namespace,
 		"app_name=", event.AppName,
@@ -62,53 +66,52 @@ func (s *GitService) Handle(event GitPushEvent) {
 	ns, err := domain.NewNamespace(event.Namespace)
 	if err != nil {
 		log.Println("[webhook][error]", err)
-		return
+		return false
 	}
 	if ns == nil {
 		log.Printf("[webhook][error] namespace [%s] not found", event.Namespace)
-		return
+		return false
 	}
 
 	app, found := ns.FindApp(event.AppName)
 	if !found {
 		log.Printf("[webhook][error] app [%s] not found", event.AppName)
-		return
+		return false
 	}
 	if app.Trigger.Type != string(event.RefType) {
 		log.Printf("[webhook][info] app [%s] update ref type is [%s],current ref type [%s]", event.AppName, app.Trigger.Type, event.RefType)
-		return
+		return false
 	}
 
 	if event.RefType == "branch" && app.Trigger.Rule != event.RefName {
 		log.Printf("[webhook][info] app [%s] update ref type is [%s/%s],current ref type [%s/%s]", event.AppName, app.Trigger.Type, app.Trigger.Rule, event.RefType, event.RefName)
-		return
+		return false
 	}
 
 	if event.RefType == "tag" && !s.matchTriggerRule(app.Trigger.Rule, event.RefName) {
 		log.Printf("[webhook][info] app [%s] update ref type is [%s/%s],current ref type [%s/%s]", event.AppName, app.Trigger.Type, app.Trigger.Rule, event.RefType, event.RefName)
-		return
+		return false
 	}
 
-	opt := usecase.DeployAppOptions{
+	job := queue.Job{
 		Namespace: event.Namespace,
-		Name:      event.AppName,
+		App:       event.AppName,
+		Trigger:   event.Provider,
 	}
 	switch event.RefType {
 	case "branch":
-		opt.Branch = strings.Replace(event.Ref, "refs/heads/", "", 1)
-		opt.Commit = event.Commit
+		job.Branch = strings.Replace(event.Ref, "refs/heads/", "", 1)
+		job.Commit = event.Commit
 	case "tag":
-		opt.Tag = event.RefName
+		job.Tag = event.RefName
 	default:
 		log.Printf("[webhook][error] err RefType [%s]", event.RefType)
-		return
+		return false
 	}
 
-	err = usecase.DeployApp(opt)
-	if err != nil {
-		panic(err)
-		log.Println("[webhook][error] DeployApp error", err)
-		return
+	if err := s.queue.Enqueue(job); err != nil {
+		log.Println("[webhook][error] enqueue deploy error", err)
+		return false
 	}
-
+	return true
 }
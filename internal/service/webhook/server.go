@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"runtime/debug"
 )
 
 type Server struct {
@@ -25,7 +26,7 @@ func NewServer(addr string, gitService *GitService) *Server {
 	return &Server{
 		httpServer: &http.Server{
 			Addr:    addr,
-			Handler: mux,
+			Handler: recoverMiddleware(mux),
 		},
 	}
 }
@@ -44,3 +45,16 @@ func (s *Server) Start(ctx context.Context) {
 		_ = s.httpServer.Shutdown(context.Background())
 	}()
 }
+
+// recoverMiddleware 单个请求 panic 时返回 500，daemon 继续服务
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("[webhook][panic] %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
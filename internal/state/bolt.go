@@ -0,0 +1,130 @@
+package state
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// openTimeout 等待其他进程释放数据库文件锁的最长时间
+const openTimeout = 10 * time.Second
+
+// BoltStore 基于 bbolt 的内嵌数据库，每种 Kind 一个 bucket
+// 每次操作打开、关闭一次数据库，CLI 与 daemon 通过 bbolt 的文件锁互斥
+type BoltStore struct {
+	path string
+}
+
+func NewBoltStore(path string) *BoltStore {
+	return &BoltStore{path: path}
+}
+
+func (s *BoltStore) Name() string {
+	return "bolt"
+}
+
+func (s *BoltStore) open(readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(s.path, 0600, &bolt.Options{
+		Timeout:  openTimeout,
+		ReadOnly: readOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open state db %s: %w", s.path, err)
+	}
+	return db, nil
+}
+
+func (s *BoltStore) view(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+func (s *BoltStore) update(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+func (s *BoltStore) Get(kind Kind, key string) ([]byte, error) {
+	var data []byte
+	err := s.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(kind))
+		if bucket == nil {
+			return nil
+		}
+		if v := bucket.Get([]byte(key)); v != nil {
+			// bbolt 返回的切片只在事务内有效
+			data = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%w: %s/%s", ErrNotFound, kind, key)
+	}
+	return data, nil
+}
+
+func (s *BoltStore) Put(kind Kind, key string, data []byte) error {
+	return s.Update(kind, key, func([]byte) ([]byte, error) {
+		return data, nil
+	})
+}
+
+func (s *BoltStore) Update(kind Kind, key string, fn UpdateFunc) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(kind))
+		if err != nil {
+			return err
+		}
+
+		var old []byte
+		if v := bucket.Get([]byte(key)); v != nil {
+			old = append([]byte(nil), v...)
+		}
+
+		data, err := fn(old)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), data)
+	})
+}
+
+func (s *BoltStore) Delete(kind Kind, key string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(kind))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+func (s *BoltStore) List(kind Kind) ([]string, error) {
+	keys := []string{}
+	err := s.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(kind))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, _ []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,217 @@
+package state
+
+import (
+	"dockflow/internal/service/filesystem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// FileStore 原有的文件布局
+// - config：/etc/dockflow/dockflow.yaml
+// - namespace：/var/lib/dockflow/namespace/<ns>/namespace.json
+// - secret：/var/lib/dockflow/secrets/<name>.json
+// 每个文件一把 advisory 锁，写入先写临时文件再 rename
+// 状态中包含 token / 密码（或其引用），文件只有 root 可读写
+type FileStore struct{}
+
+func NewFileStore() *FileStore {
+	return &FileStore{}
+}
+
+func (s *FileStore) Name() string {
+	return "file"
+}
+
+func (s *FileStore) path(kind Kind, key string) (string, error) {
+	switch kind {
+	case KindConfig:
+		return filesystem.CfgPath, nil
+	case KindNamespace:
+		return filepath.Join(filesystem.NamespaceDirName, key, "namespace.json"), nil
+	case KindSecret:
+		return filepath.Join(filesystem.SecretDirName, key+".json"), nil
+	default:
+		return "", fmt.Errorf("unknown state kind: %s", kind)
+	}
+}
+
+func (s *FileStore) Get(kind Kind, key string) ([]byte, error) {
+	path, err := s.path(kind, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		return nil, err
+	}
+
+	unlock, err := lockFile(path, syscall.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return os.ReadFile(path)
+}
+
+func (s *FileStore) Put(kind Kind, key string, data []byte) error {
+	return s.Update(kind, key, func([]byte) ([]byte, error) {
+		return data, nil
+	})
+}
+
+func (s *FileStore) Update(kind Kind, key string, fn UpdateFunc) error {
+	path, err := s.path(kind, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// secret 目录不允许其他用户列出
+	if kind == KindSecret {
+		if err := os.Chmod(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := lockFile(path, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	old, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	data, err := fn(old)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+func (s *FileStore) Delete(kind Kind, key string) error {
+	path, err := s.path(kind, key)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	unlock, err := lockFile(path, syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) List(kind Kind) ([]string, error) {
+	switch kind {
+	case KindConfig:
+		if _, err := os.Stat(filesystem.CfgPath); err != nil {
+			if os.IsNotExist(err) {
+				return []string{}, nil
+			}
+			return nil, err
+		}
+		return []string{ConfigKey}, nil
+	case KindNamespace:
+		entries, err := os.ReadDir(filesystem.NamespaceDirName)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return []string{}, nil
+			}
+			return nil, err
+		}
+
+		keys := []string{}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(filesystem.NamespaceDirName, entry.Name(), "namespace.json")); err == nil {
+				keys = append(keys, entry.Name())
+			}
+		}
+		return keys, nil
+	case KindSecret:
+		entries, err := os.ReadDir(filesystem.SecretDirName)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return []string{}, nil
+			}
+			return nil, err
+		}
+
+		keys := []string{}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+				continue
+			}
+			keys = append(keys, strings.TrimSuffix(name, ".json"))
+		}
+		return keys, nil
+	default:
+		return nil, fmt.Errorf("unknown state kind: %s", kind)
+	}
+}
+
+// writeFileAtomic 先写临时文件再 rename，读者不会看到写了一半的文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// lockFile 文件级别的 advisory 锁，CLI 与 daemon 之间互斥
+// 锁加在同目录的 .<name>.lock 上，rename 替换数据文件不影响锁
+func lockFile(path string, how int) (func(), error) {
+	lockPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"dockflow/internal/service/filesystem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	ErrAlreadyMigrated = errors.New("state already migrated to embedded database")
+)
+
+// MigrateResult 每种状态迁移的条数
+type MigrateResult map[Kind]int
+
+// Migrate 将 from 中的全部状态复制到 to，to 中已存在的同名 key 会被覆盖
+// from 中的数据保持不变，作为备份
+func Migrate(from, to Store) (MigrateResult, error) {
+	result := MigrateResult{}
+	for _, kind := range Kinds {
+		keys, err := from.List(kind)
+		if err != nil {
+			return result, fmt.Errorf("list %s: %w", kind, err)
+		}
+
+		for _, key := range keys {
+			data, err := from.Get(kind, key)
+			if err != nil {
+				return result, fmt.Errorf("read %s/%s: %w", kind, key, err)
+			}
+			if err := to.Put(kind, key, data); err != nil {
+				return result, fmt.Errorf("write %s/%s: %w", kind, key, err)
+			}
+			result[kind]++
+		}
+	}
+	return result, nil
+}
+
+// MigrateToBolt 将文件状态迁移到 StateDBFile
+// 先写入临时数据库，全部成功后再 rename，避免迁移一半时 Default() 切换到不完整的数据库
+func MigrateToBolt() (MigrateResult, error) {
+	if _, err := os.Stat(filesystem.StateDBFile); err == nil {
+		return nil, ErrAlreadyMigrated
+	}
+
+	tmp := filesystem.StateDBFile + ".migrating"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	result, err := Migrate(NewFileStore(), NewBoltStore(tmp))
+	if err != nil {
+		_ = os.Remove(tmp)
+		return result, err
+	}
+
+	if err := os.Rename(tmp, filesystem.StateDBFile); err != nil {
+		_ = os.Remove(tmp)
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"dockflow/internal/service/filesystem"
+	"errors"
+	"os"
+)
+
+// Kind 状态类型，每种类型下按 key 保存一份数据
+type Kind string
+
+const (
+	// KindConfig dockflow.yaml，key 固定为 ConfigKey
+	KindConfig Kind = "config"
+	// KindNamespace namespace.json，key 为 namespace 名称
+	KindNamespace Kind = "namespace"
+	// KindSecret 加密后的 secret，key 为 secret 名称
+	KindSecret Kind = "secret"
+)
+
+const ConfigKey = "dockflow"
+
+// Kinds 所有状态类型，迁移时按此顺序复制
+var Kinds = []Kind{KindConfig, KindNamespace, KindSecret}
+
+var (
+	ErrNotFound = errors.New("state not found")
+)
+
+// UpdateFunc 读取 → 修改 → 写入，old 为 nil 表示尚不存在
+// 返回错误时不写入
+type UpdateFunc func(old []byte) ([]byte, error)
+
+// Store 状态存储，保存序列化后的原始数据，编解码由调用方负责
+// 实现需要保证 Update 在进程之间也是原子的
+type Store interface {
+	Name() string
+	Get(kind Kind, key string) ([]byte, error)
+	Put(kind Kind, key string, data []byte) error
+	Update(kind Kind, key string, fn UpdateFunc) error
+	Delete(kind Kind, key string) error
+	List(kind Kind) ([]string, error)
+}
+
+// Default 当前使用的存储
+// 执行过 `dockflow state migrate`（StateDBFile 存在）时使用 BoltStore，否则使用 FileStore
+func Default() Store {
+	if _, err := os.Stat(filesystem.StateDBFile); err == nil {
+		return NewBoltStore(filesystem.StateDBFile)
+	}
+	return NewFileStore()
+}
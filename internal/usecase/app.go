@@ -6,9 +6,12 @@ import (
 	"dockflow/internal/service"
 	"dockflow/internal/service/docker"
 	"dockflow/internal/service/git"
+	"dockflow/internal/service/secret"
+	"dockflow/internal/service/traefik"
 	"dockflow/internal/util"
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/samber/lo"
@@ -21,14 +24,20 @@ var (
 	// ErrdatabaseNotSuppert = errors.New("database not suppert")
 )
 
-func CreateApp(app domain.AppSpec) error {
+// CreateAppResult WebhookSecret 是生成的 webhook secret 明文，只在创建时返回，保存的是 secret 引用
+type CreateAppResult struct {
+	App           domain.AppSpec
+	WebhookSecret string
+}
+
+func CreateApp(app domain.AppSpec) (*CreateAppResult, error) {
 	// ---------- load namespace ----------
 	ns, err := domain.NewNamespace(app.Namespace)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if ns == nil {
-		return ErrNamespaceNotFound
+		return nil, ErrNamespaceNotFound
 	}
 
 	// ---------- duplicate check ----------
@@ -36,9 +45,74 @@ func CreateApp(app domain.AppSpec) error {
 		return s.Name == app.Name
 	})
 	if found {
-		return fmt.Errorf("app name [%s] is exist", app.Name)
+		return nil, fmt.Errorf("app name [%s] is exist", app.Name)
+	}
+
+	if err := validateAppSpec(app); err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	result := &CreateAppResult{}
+	if cfg.WebHookUrl != "" {
+		app.Secret = util.GenerateRandomString(32)
+		result.WebhookSecret = app.Secret
+		gitinfo, err := domain.NewGitUrl(app.Repo)
+		if err != nil {
+			return nil, err
+		}
+
+		_token, err := secret.Resolve(app.Token)
+		if err != nil {
+			return nil, err
+		}
+		if _token == "" {
+			_token, err = config.FindGit(gitinfo.Host, gitinfo.Username)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		opt := git.WebhookOption{
+			Repo:        app.Repo,
+			Secret:      app.Secret,
+			Token:       _token,
+			CallbackURL: fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(cfg.WebHookUrl, "/"), app.Namespace, app.Name),
+		}
+		err = git.NormalizeWebhookOption(opt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// ---------- secrets ----------
+	if err := sealAppSecrets(&app); err != nil {
+		return nil, err
 	}
 
+	// ---------- append & save ----------
+	err = domain.UpdateNamespace(app.Namespace, func(ns *domain.Namespace) error {
+		if _, found := ns.FindApp(app.Name); found {
+			return fmt.Errorf("app name [%s] is exist", app.Name)
+		}
+		ns.App = append(ns.App, app)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.App = app
+	return result, nil
+}
+
+// platformPattern 构建平台，os/arch[/variant]；镜像加载到本机 daemon，只支持单个平台
+var platformPattern = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+
+// validateAppSpec 创建与 apply 更新共用的校验
+func validateAppSpec(app domain.AppSpec) error {
 	// ---------- basic validate ----------
 	if app.Name == "" {
 		return fmt.Errorf("service name is required")
@@ -61,6 +135,21 @@ func CreateApp(app domain.AppSpec) error {
 		return fmt.Errorf("trigger rule is required")
 	}
 
+	// ---------- platform validate ----------
+	if app.Platform != "" && !platformPattern.MatchString(app.Platform) {
+		return fmt.Errorf("invalid platform: %s (expect os/arch, e.g. linux/arm64)", app.Platform)
+	}
+
+	// ---------- build arg validate ----------
+	if err := service.ValidateBuildArgs(app.BuildArg); err != nil {
+		return err
+	}
+
+	// ---------- retention validate ----------
+	if app.Retention != nil && app.Retention.KeepVersions < 1 {
+		return fmt.Errorf("invalid keep versions: %d (expect >= 1)", app.Retention.KeepVersions)
+	}
+
 	// ---------- env validate ----------
 	for _, env := range app.Envs {
 		if env.Key == "" {
@@ -82,42 +171,14 @@ func CreateApp(app domain.AppSpec) error {
 		}
 	}
 
-	cfg, err := config.Load()
-	if err != nil {
-		return err
-	}
-	if cfg.WebHookUrl != "" {
-		app.Secret = util.GenerateRandomString(32)
-		gitinfo, err := domain.NewGitUrl(app.Repo)
-		if err != nil {
+	// ---------- health validate ----------
+	if app.Health != nil {
+		if err := app.Health.Validate(); err != nil {
 			return err
 		}
-
-		_token := app.Token
-		if _token == "" {
-			_token, err = config.FindGit(gitinfo.Host, gitinfo.Username)
-			if err != nil {
-				return err
-			}
+		if app.HealthPort() == "" {
+			return fmt.Errorf("health port is required when the app has no urls")
 		}
-
-		opt := git.WebhookOption{
-			Repo:        app.Repo,
-			Secret:      app.Secret,
-			Token:       _token,
-			CallbackURL: fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(cfg.WebHookUrl, "/"), app.Namespace, app.Name),
-		}
-		err = git.NormalizeWebhookOption(opt)
-		if err != nil {
-			return err
-		}
-	}
-
-	// ---------- append & save ----------
-	ns.App = append(ns.App, app)
-
-	if err := ns.Save(); err != nil {
-		return err
 	}
 
 	return nil
@@ -141,9 +202,19 @@ type DeployAppOptions struct {
 	Branch    string
 	Commit    string
 	Tag       string
+	Trigger   domain.DeployTrigger // 默认 cli
+	BuildArgs map[string]*string   // 覆盖 app 的 BuildArg，只对本次部署生效
+	Platform  string               // 覆盖 app 的 Platform，只对本次部署生效
 }
 
 func DeployApp(opt DeployAppOptions) error {
+	if opt.Platform != "" && !platformPattern.MatchString(opt.Platform) {
+		return fmt.Errorf("invalid platform: %s (expect os/arch, e.g. linux/arm64)", opt.Platform)
+	}
+	if err := service.ValidateBuildArgs(opt.BuildArgs); err != nil {
+		return err
+	}
+
 	namespace, err := domain.NewNamespace(opt.Namespace)
 	if err != nil {
 		return err
@@ -159,7 +230,10 @@ func DeployApp(opt DeployAppOptions) error {
 			if err != nil {
 				return err
 			}
-			err = deploy.Deploy(&opt.Branch, &opt.Commit, &opt.Tag)
+			err = deploy.Deploy(opt.Trigger, &opt.Branch, &opt.Commit, &opt.Tag, service.BuildOverrides{
+				Args:     opt.BuildArgs,
+				Platform: opt.Platform,
+			})
 			if err != nil {
 				return err
 			}
@@ -170,6 +244,35 @@ func DeployApp(opt DeployAppOptions) error {
 	return fmt.Errorf("app name [%s] not found", opt.Name)
 }
 
+type RollbackAppOptions struct {
+	Namespace string
+	Name      string
+	To        string               // 为空时回滚到上一个成功版本
+	Trigger   domain.DeployTrigger // 默认 cli
+}
+
+// RollbackApp 返回回滚前后的版本
+func RollbackApp(opt RollbackAppOptions) (string, string, error) {
+	ns, err := domain.NewNamespace(opt.Namespace)
+	if err != nil {
+		return "", "", err
+	}
+	if ns == nil {
+		return "", "", ErrNamespaceNotFound
+	}
+
+	app, found := ns.FindApp(opt.Name)
+	if !found {
+		return "", "", ErrAppNotFound
+	}
+
+	deploy, err := service.NewAppDeployer(&app)
+	if err != nil {
+		return "", "", err
+	}
+	return deploy.Rollback(opt.To, opt.Trigger)
+}
+
 func RemoveApp(nsName, appName string) error {
 	ns, err := domain.NewNamespace(nsName)
 	if err != nil {
@@ -193,8 +296,21 @@ func RemoveApp(nsName, appName string) error {
 		if err != nil {
 			return err
 		}
+		err = traefik.RemoveAppRoutes(app.Name, deploy.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = domain.UpdateNamespace(nsName, func(ns *domain.Namespace) error {
+		ns.RemoveApp(appName)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	ns.RemoveApp(appName)
+	appNs := domain.Namespace{Name: nsName, App: []domain.AppSpec{app}}
+	dropOwnedSecrets(appSecretName(nsName, appName, ""), namespaceSecretFields(&appNs))
 	return nil
 }
@@ -0,0 +1,409 @@
+package usecase
+
+import (
+	"dockflow/internal/domain"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type PlanAction string
+
+const (
+	PlanCreate PlanAction = "create"
+	PlanUpdate PlanAction = "update"
+	PlanDelete PlanAction = "delete"
+	// PlanDrift redis / database 配置与 manifest 不一致，需要重建容器，apply 不会自动处理
+	PlanDrift PlanAction = "drift"
+	// PlanExtra 状态中存在、manifest 中没有，--prune 时变为 PlanDelete
+	PlanExtra PlanAction = "extra"
+)
+
+type PlanItem struct {
+	Kind    domain.ResourceKind `json:"kind"`
+	Name    string              `json:"name"`
+	Action  PlanAction          `json:"action"`
+	Changes []string            `json:"changes,omitempty"`
+}
+
+type ApplyPlan struct {
+	Namespace       string     `json:"namespace"`
+	CreateNamespace bool       `json:"createNamespace"`
+	Items           []PlanItem `json:"items"`
+}
+
+// Empty 没有需要执行的变更（drift / extra 只报告）
+func (p *ApplyPlan) Empty() bool {
+	if p.CreateNamespace {
+		return false
+	}
+	for _, item := range p.Items {
+		switch item.Action {
+		case PlanCreate, PlanUpdate, PlanDelete:
+			return false
+		}
+	}
+	return true
+}
+
+type ApplyOptions struct {
+	Manifest *domain.StackManifest
+	DryRun   bool
+	Prune    bool
+}
+
+// PlanStack 对比 manifest 与当前状态
+func PlanStack(m *domain.StackManifest, prune bool) (*ApplyPlan, error) {
+	plan := &ApplyPlan{
+		Namespace: m.Namespace,
+		Items:     []PlanItem{},
+	}
+
+	ns, err := domain.NewNamespace(m.Namespace)
+	if err != nil && !errors.Is(err, domain.ErrNamespaceNotFound) {
+		return nil, err
+	}
+	if ns == nil {
+		ns = &domain.Namespace{Name: m.Namespace}
+		plan.CreateNamespace = true
+	}
+
+	extra := PlanExtra
+	if prune {
+		extra = PlanDelete
+	}
+
+	// ---------- database ----------
+	for _, desired := range m.Databases {
+		spec := desired.DatabaseSpec(m.Namespace)
+		current, found := lo.Find(ns.Database, func(d domain.DatabaseSpec) bool { return d.Name == spec.Name })
+		if !found {
+			plan.add(domain.ResourceDatabase, spec.Name, PlanCreate, nil)
+			continue
+		}
+		if changes := diffDatabase(current, spec); len(changes) > 0 {
+			plan.add(domain.ResourceDatabase, spec.Name, PlanDrift, changes)
+		}
+	}
+	for _, current := range ns.Database {
+		if !lo.ContainsBy(m.Databases, func(d domain.StackDatabase) bool { return d.Name == current.Name }) {
+			plan.add(domain.ResourceDatabase, current.Name, extra, nil)
+		}
+	}
+
+	// ---------- redis ----------
+	for _, desired := range m.Redis {
+		spec := desired.RedisSpec(m.Namespace)
+		current, found := lo.Find(ns.Redis, func(r domain.RedisSpec) bool { return r.Name == spec.Name })
+		if !found {
+			plan.add(domain.ResourceRedis, spec.Name, PlanCreate, nil)
+			continue
+		}
+		if changes := diffRedis(current, spec); len(changes) > 0 {
+			plan.add(domain.ResourceRedis, spec.Name, PlanDrift, changes)
+		}
+	}
+	for _, current := range ns.Redis {
+		if !lo.ContainsBy(m.Redis, func(r domain.StackRedis) bool { return r.Name == current.Name }) {
+			plan.add(domain.ResourceRedis, current.Name, extra, nil)
+		}
+	}
+
+	// ---------- app ----------
+	for _, desired := range m.Apps {
+		spec := desired.AppSpec(m.Namespace)
+		if err := validateAppSpec(spec); err != nil {
+			return nil, fmt.Errorf("app [%s]: %w", spec.Name, err)
+		}
+		for _, link := range spec.Links {
+			if !stackHasLinkTarget(m, ns, link) {
+				return nil, fmt.Errorf("app [%s]: linked %s [%s] not found", spec.Name, link.Kind, link.Name)
+			}
+		}
+
+		current, found := ns.FindApp(spec.Name)
+		if !found {
+			plan.add(domain.ResourceApp, spec.Name, PlanCreate, nil)
+			continue
+		}
+		if changes := diffApp(current, spec); len(changes) > 0 {
+			plan.add(domain.ResourceApp, spec.Name, PlanUpdate, changes)
+		}
+	}
+	for _, current := range ns.App {
+		if !lo.ContainsBy(m.Apps, func(a domain.StackApp) bool { return a.Name == current.Name }) {
+			plan.add(domain.ResourceApp, current.Name, extra, nil)
+		}
+	}
+
+	return plan, nil
+}
+
+// stackHasLinkTarget 链接的 redis / database 在 manifest 中或已经存在
+func stackHasLinkTarget(m *domain.StackManifest, ns *domain.Namespace, link domain.AppLink) bool {
+	switch link.Kind {
+	case domain.ResourceRedis:
+		_, found := ns.FindRedis(link.Name)
+		return found || lo.ContainsBy(m.Redis, func(r domain.StackRedis) bool { return r.Name == link.Name })
+	case domain.ResourceDatabase:
+		_, found := ns.FindDatabase(link.Name)
+		return found || lo.ContainsBy(m.Databases, func(d domain.StackDatabase) bool { return d.Name == link.Name })
+	}
+	return false
+}
+
+func (p *ApplyPlan) add(kind domain.ResourceKind, name string, action PlanAction, changes []string) {
+	p.Items = append(p.Items, PlanItem{
+		Kind:    kind,
+		Name:    name,
+		Action:  action,
+		Changes: changes,
+	})
+}
+
+// ApplyStack 按计划创建 / 更新 / 删除资源，遇到错误立即停止
+// 顺序：namespace → database → redis → app，删除在最后
+// app 更新只修改状态，下次部署时生效
+func ApplyStack(opt ApplyOptions) (*ApplyPlan, error) {
+	m := opt.Manifest
+	plan, err := PlanStack(m, opt.Prune)
+	if err != nil {
+		return nil, err
+	}
+	if opt.DryRun {
+		return plan, nil
+	}
+
+	if plan.CreateNamespace {
+		if _, err := CreateNamespace(m.Namespace); err != nil {
+			return plan, fmt.Errorf("create namespace: %w", err)
+		}
+	}
+
+	var deletes []PlanItem
+	for _, item := range plan.Items {
+		var err error
+		switch item.Action {
+		case PlanCreate:
+			err = applyCreate(m, item)
+		case PlanUpdate:
+			err = applyUpdate(m, item)
+		case PlanDelete:
+			deletes = append(deletes, item)
+		}
+		if err != nil {
+			return plan, fmt.Errorf("%s %s [%s]: %w", item.Action, item.Kind, item.Name, err)
+		}
+	}
+
+	// 先删应用，再删应用可能依赖的 redis / database
+	for _, kind := range []domain.ResourceKind{domain.ResourceApp, domain.ResourceRedis, domain.ResourceDatabase} {
+		for _, item := range deletes {
+			if item.Kind != kind {
+				continue
+			}
+			if err := applyDelete(m.Namespace, item); err != nil {
+				return plan, fmt.Errorf("delete %s [%s]: %w", item.Kind, item.Name, err)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+func applyCreate(m *domain.StackManifest, item PlanItem) error {
+	switch item.Kind {
+	case domain.ResourceDatabase:
+		desired, _ := lo.Find(m.Databases, func(d domain.StackDatabase) bool { return d.Name == item.Name })
+		return Createdatabase(desired.DatabaseSpec(m.Namespace))
+	case domain.ResourceRedis:
+		desired, _ := lo.Find(m.Redis, func(r domain.StackRedis) bool { return r.Name == item.Name })
+		return CreateRedis(desired.RedisSpec(m.Namespace))
+	case domain.ResourceApp:
+		desired, _ := lo.Find(m.Apps, func(a domain.StackApp) bool { return a.Name == item.Name })
+		_, err := CreateApp(desired.AppSpec(m.Namespace))
+		return err
+	}
+	return nil
+}
+
+// applyUpdate 只更新 manifest 描述的字段，保留部署记录、webhook secret 等
+func applyUpdate(m *domain.StackManifest, item PlanItem) error {
+	if item.Kind != domain.ResourceApp {
+		return nil
+	}
+
+	desired, _ := lo.Find(m.Apps, func(a domain.StackApp) bool { return a.Name == item.Name })
+	spec := desired.AppSpec(m.Namespace)
+	if err := sealAppSecrets(&spec); err != nil {
+		return err
+	}
+
+	return domain.UpdateNamespace(m.Namespace, func(ns *domain.Namespace) error {
+		for i := range ns.App {
+			if ns.App[i].Name != spec.Name {
+				continue
+			}
+			app := &ns.App[i]
+			app.Repo = spec.Repo
+			app.Token = spec.Token
+			app.CPU = spec.CPU
+			app.Memory = spec.Memory
+			app.Trigger = spec.Trigger
+			app.Envs = spec.Envs
+			app.URLs = spec.URLs
+			app.Health = spec.Health
+			app.Links = spec.Links
+			app.Retention = spec.Retention
+			app.MarkNeedsRedeploy()
+			return nil
+		}
+		return ErrAppNotFound
+	})
+}
+
+func applyDelete(namespace string, item PlanItem) error {
+	switch item.Kind {
+	case domain.ResourceApp:
+		return RemoveApp(namespace, item.Name)
+	case domain.ResourceRedis:
+		return RemoveRedis(namespace, item.Name)
+	case domain.ResourceDatabase:
+		return Removedatabase(namespace, item.Name)
+	}
+	return nil
+}
+
+//
+// ==========================
+// Diff
+// ==========================
+//
+
+func diffApp(current, desired domain.AppSpec) []string {
+	var changes []string
+	if current.Repo != desired.Repo {
+		changes = append(changes, fmt.Sprintf("repo: %s -> %s", current.Repo, desired.Repo))
+	}
+	if secretChanged(current.Token, desired.Token) {
+		changes = append(changes, "token changed")
+	}
+	if current.CPU != desired.CPU {
+		changes = append(changes, fmt.Sprintf("cpu: %g -> %g", current.CPU, desired.CPU))
+	}
+	if current.Memory != desired.Memory {
+		changes = append(changes, fmt.Sprintf("memory: %dG -> %dG", current.Memory, desired.Memory))
+	}
+	if current.Trigger != desired.Trigger {
+		changes = append(changes, fmt.Sprintf("trigger: %s/%s -> %s/%s",
+			current.Trigger.Type, current.Trigger.Rule, desired.Trigger.Type, desired.Trigger.Rule))
+	}
+	changes = append(changes, diffEnvs(current.Envs, desired.Envs)...)
+	if !reflect.DeepEqual(lo.Map(current.URLs, formatURL), lo.Map(desired.URLs, formatURL)) {
+		changes = append(changes, fmt.Sprintf("url: %s -> %s",
+			strings.Join(lo.Map(current.URLs, formatURL), ","), strings.Join(lo.Map(desired.URLs, formatURL), ",")))
+	}
+	if !reflect.DeepEqual(current.Health, desired.Health) {
+		changes = append(changes, "health check changed")
+	}
+	if formatLinks(current.Links) != formatLinks(desired.Links) {
+		changes = append(changes, fmt.Sprintf("links: %s -> %s", formatLinks(current.Links), formatLinks(desired.Links)))
+	}
+	if !reflect.DeepEqual(current.Retention, desired.Retention) {
+		changes = append(changes, fmt.Sprintf("keep versions: %s -> %s", formatRetention(current.Retention), formatRetention(desired.Retention)))
+	}
+	return changes
+}
+
+// diffEnvs 只输出 key，不输出值
+func diffEnvs(current, desired []domain.Env) []string {
+	var changes []string
+	currentMap := lo.KeyBy(current, func(e domain.Env) string { return e.Key })
+	desiredMap := lo.KeyBy(desired, func(e domain.Env) string { return e.Key })
+
+	for _, env := range desired {
+		old, ok := currentMap[env.Key]
+		switch {
+		case !ok:
+			changes = append(changes, "env +"+env.Key)
+		case old.Secret != env.Secret || secretChanged(old.Value, env.Value):
+			changes = append(changes, "env ~"+env.Key)
+		}
+	}
+	for _, env := range current {
+		if _, ok := desiredMap[env.Key]; !ok {
+			changes = append(changes, "env -"+env.Key)
+		}
+	}
+	return changes
+}
+
+func formatRetention(r *domain.Retention) string {
+	if r == nil {
+		return "default"
+	}
+	return fmt.Sprint(r.KeepVersions)
+}
+
+func formatLinks(links []domain.AppLink) string {
+	if len(links) == 0 {
+		return "-"
+	}
+	return strings.Join(lo.Map(links, func(l domain.AppLink, _ int) string { return l.String() }), ",")
+}
+
+func formatURL(u domain.AppURL, _ int) string {
+	return u.Host + ":" + u.Port
+}
+
+func diffRedis(current, desired domain.RedisSpec) []string {
+	var changes []string
+	if current.Version != desired.Version {
+		changes = append(changes, fmt.Sprintf("version: %s -> %s", current.Version, desired.Version))
+	}
+	if current.CPU != desired.CPU {
+		changes = append(changes, fmt.Sprintf("cpu: %g -> %g", current.CPU, desired.CPU))
+	}
+	if current.Memory != desired.Memory {
+		changes = append(changes, fmt.Sprintf("memory: %gG -> %gG", current.Memory, desired.Memory))
+	}
+	if secretChanged(current.Password, desired.Password) {
+		changes = append(changes, "password changed")
+	}
+	if current.AOF != desired.AOF {
+		changes = append(changes, fmt.Sprintf("aof: %t -> %t", current.AOF, desired.AOF))
+	}
+	if current.Eviction != desired.Eviction {
+		changes = append(changes, fmt.Sprintf("eviction: %s -> %s", current.Eviction, desired.Eviction))
+	}
+	return changes
+}
+
+func diffDatabase(current, desired domain.DatabaseSpec) []string {
+	var changes []string
+	if current.DbType != desired.DbType {
+		changes = append(changes, fmt.Sprintf("dbType: %s -> %s", current.DbType, desired.DbType))
+	}
+	if current.CPU != desired.CPU {
+		changes = append(changes, fmt.Sprintf("cpu: %g -> %g", current.CPU, desired.CPU))
+	}
+	if current.Memory != desired.Memory {
+		changes = append(changes, fmt.Sprintf("memory: %gG -> %gG", current.Memory, desired.Memory))
+	}
+	if current.Username != desired.Username {
+		changes = append(changes, "username changed")
+	}
+	if secretChanged(current.Password, desired.Password) {
+		changes = append(changes, "password changed")
+	}
+	if current.DbName != desired.DbName {
+		changes = append(changes, fmt.Sprintf("dbName: %s -> %s", current.DbName, desired.DbName))
+	}
+	if current.Remote != desired.Remote {
+		changes = append(changes, fmt.Sprintf("remote: %t -> %t", current.Remote, desired.Remote))
+	}
+	return changes
+}
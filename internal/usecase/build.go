@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"dockflow/internal/service/docker"
+	"fmt"
+	"time"
+)
+
+type PruneBuildCacheResult struct {
+	Removed   int
+	Reclaimed uint64 // bytes
+	BuildKit  bool   // 构建是否使用 BuildKit，否则缓存挂载不会产生
+}
+
+// PruneBuildCache 清理构建缓存（镜像层缓存与 app 的依赖缓存挂载）
+// olderThan > 0 时只清理超过该时间未使用的
+func PruneBuildCache(olderThan time.Duration, all bool) (*PruneBuildCacheResult, error) {
+	if olderThan < 0 {
+		return nil, fmt.Errorf("invalid older-than: %s", olderThan)
+	}
+
+	report, err := docker.PruneBuildCache(olderThan, all)
+	if err != nil {
+		return nil, err
+	}
+	return &PruneBuildCacheResult{
+		Removed:   len(report.CachesDeleted),
+		Reclaimed: report.SpaceReclaimed,
+		BuildKit:  docker.BuildKitAvailable(),
+	}, nil
+}
@@ -4,11 +4,11 @@ import (
 	"dockflow/internal/domain"
 	"dockflow/internal/service/docker"
 	"dockflow/internal/service/filesystem"
+	"dockflow/internal/service/secret"
 	"dockflow/internal/util"
 	"errors"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
@@ -39,57 +39,70 @@ func Createdatabase(database domain.DatabaseSpec) error {
 		return ErrdatabaseExist
 	}
 
-	name := strings.ToLower(database.DbType)
-
-	if idx := strings.LastIndex(name, "/"); idx != -1 {
-		name = name[idx+1:]
-	}
-	if idx := strings.Index(name, ":"); idx != -1 {
-		name = name[:idx]
-	}
+	name := database.Engine()
 	switch name {
-	case "mysql", "postgres", "postgresql":
+	case "mysql", "postgres":
 	default:
 		return ErrdatabaseNotSuppert
 	}
 
+	database.Password, err = sealSecret(resourceSecretName(database.Namespace, domain.ResourceDatabase, database.Name), database.Password)
+	if err != nil {
+		return err
+	}
+
+	containerId, ips, err := runDatabase(ns.Network, database)
+	if err != nil {
+		removeUntrackedContainer(containerId)
+		return err
+	}
+
+	database.ContainerId = containerId
+	database.Ip = ips
+
+	err = domain.UpdateNamespace(database.Namespace, func(ns *domain.Namespace) error {
+		if lo.ContainsBy(ns.Database, func(d domain.DatabaseSpec) bool { return d.Name == database.Name }) {
+			return ErrdatabaseExist
+		}
+		ns.Database = append(ns.Database, database)
+		return nil
+	})
+	if err != nil {
+		removeUntrackedContainer(containerId)
+	}
+	return err
+}
+
+// runDatabase 拉取镜像并启动数据库容器，数据卷按名称复用，reconcile 重建容器时复用
+func runDatabase(network string, database domain.DatabaseSpec) (string, []string, error) {
 	databaseImageName := database.DbType
 	if err := docker.PullImage(databaseImageName); err != nil {
-		return err
+		return "", nil, err
 	}
 
 	opts := docker.NewRunOptions(database.Name, databaseImageName)
 	opts.WithRestart(container.RestartPolicyOnFailure)
-	opts.WithNetwork(ns.Network)
+	opts.WithNetwork(network)
 	opts.WithCpu(database.CPU)
 	opts.WithMemory(database.Memory)
+	opts.WithLabel(domain.LabelNamespace, database.Namespace)
+	opts.WithLabel(domain.LabelName, database.Name)
+	opts.WithLabel(domain.LabelKind, string(domain.ResourceDatabase))
 
-	err = detectDatabaseType(database, opts)
-	if err != nil {
-		return err
+	if err := detectDatabaseType(database, opts); err != nil {
+		return "", nil, err
 	}
 
 	containerId, err := docker.RunContainer(opts)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
-	inspect, err := docker.InspectContainer(containerId)
+	ips, err := containerIPs(containerId)
 	if err != nil {
-		return err
-	}
-
-	ips := []string{}
-	for _, net := range inspect.NetworkSettings.Networks {
-		ips = append(ips, net.IPAddress)
+		return containerId, nil, err
 	}
-
-	database.ContainerId = containerId
-	database.Ip = ips
-	ns.Database = append(ns.Database, database)
-	ns.Save()
-
-	return nil
+	return containerId, ips, nil
 }
 
 func Listdatabase(namespaceName string) ([]domain.DatabaseSpec, error) {
@@ -113,12 +126,15 @@ func Removedatabase(namespaceName string, databaseContainerName string) error {
 		return ErrNamespaceNotFound
 	}
 
-	database, index, found := lo.FindIndexOf(ns.Database, func(d domain.DatabaseSpec) bool {
+	database, _, found := lo.FindIndexOf(ns.Database, func(d domain.DatabaseSpec) bool {
 		return d.Name == databaseContainerName
 	})
 	if !found {
 		return ErrdatabaseNotExist
 	}
+	if err := checkNotLinked(ns, domain.ResourceDatabase, databaseContainerName); err != nil {
+		return err
+	}
 
 	containerId, err := docker.HasContainer(database.ContainerId)
 	if err != nil {
@@ -158,22 +174,27 @@ func Removedatabase(namespaceName string, databaseContainerName string) error {
 		}
 	}
 
-	ns.Database = lo.Filter(ns.Database, func(item domain.DatabaseSpec, i int) bool {
-		return index != i
+	err = domain.UpdateNamespace(namespaceName, func(ns *domain.Namespace) error {
+		ns.Database = lo.Filter(ns.Database, func(item domain.DatabaseSpec, i int) bool {
+			return item.Name != databaseContainerName
+		})
+		return nil
 	})
-	ns.Save()
+	if err != nil {
+		return err
+	}
 
+	databaseNs := domain.Namespace{Name: namespaceName, Database: []domain.DatabaseSpec{database}}
+	dropOwnedSecrets(resourceSecretName(namespaceName, domain.ResourceDatabase, database.Name), namespaceSecretFields(&databaseNs))
 	return nil
 }
 
 func detectDatabaseType(database domain.DatabaseSpec, opt *docker.ContainerRunOptions) (err error) {
-	name := strings.ToLower(database.DbType)
+	name := database.Engine()
 
-	if idx := strings.LastIndex(name, "/"); idx != -1 {
-		name = name[idx+1:]
-	}
-	if idx := strings.Index(name, ":"); idx != -1 {
-		name = name[:idx]
+	password, err := secret.Resolve(database.Password)
+	if err != nil {
+		return fmt.Errorf("database password: %w", err)
 	}
 
 	switch name {
@@ -184,20 +205,20 @@ func detectDatabaseType(database domain.DatabaseSpec, opt *docker.ContainerRunOp
 		opt.WithEnv("MYSQL_ROOT_PASSWORD", "dockflow-init-only")
 		opt.WithEnv("MYSQL_DATABASE", database.DbName)
 		opt.WithEnv("MYSQL_USER", database.Username)
-		opt.WithEnv("MYSQL_PASSWORD", database.Password)
+		opt.WithEnv("MYSQL_PASSWORD", password)
 
 		if database.Remote {
 			hostPort := util.GenerateRandomPort()
 			opt.WithPort(hostPort, 3306)
 		}
 
-	case "postgres", "postgresql":
+	case "postgres":
 		opt.WithVolume(filesystem.PgSqlInitScript, "/docker-entrypoint-initdb.d/001-dockflow.sql", "ro")
 		opt.WithVolume(fmt.Sprintf("dockflow-dbvolume-%s-%s-%s", database.Namespace, database.Name, database.DbName), "/var/lib/postgresql")
 
 		opt.WithEnv("POSTGRES_DB", database.DbName)
 		opt.WithEnv("POSTGRES_USER", database.Username)
-		opt.WithEnv("POSTGRES_PASSWORD", database.Password)
+		opt.WithEnv("POSTGRES_PASSWORD", password)
 
 		if database.Remote {
 			hostPort := util.GenerateRandomPort()
@@ -1 +1,187 @@
 package usecase
+
+import (
+	"context"
+	"dockflow/internal/domain"
+	"dockflow/internal/service"
+	"dockflow/internal/service/queue"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// RunDeployJob daemon 部署队列的执行函数
+func RunDeployJob(job queue.Job) error {
+	if job.Type == queue.JobRollback {
+		_, _, err := RollbackApp(RollbackAppOptions{
+			Namespace: job.Namespace,
+			Name:      job.App,
+			To:        job.RollbackTo,
+			Trigger:   domain.DeployTrigger(job.Trigger),
+		})
+		return err
+	}
+	return DeployApp(DeployAppOptions{
+		Namespace: job.Namespace,
+		Name:      job.App,
+		Branch:    job.Branch,
+		Commit:    job.Commit,
+		Tag:       job.Tag,
+		Trigger:   domain.DeployTrigger(job.Trigger),
+	})
+}
+
+// RecoverInterruptedDeployments daemon 启动时把中断的部署记录标记为失败
+// 崩溃或重启前未结束的部署会一直停留在 running，reconcile、gc 会把应用当作正在部署而跳过；
+// 应用锁仍被占用（CLI 正在部署）时跳过
+func RecoverInterruptedDeployments() {
+	for _, ns := range domain.ListNamespaces() {
+		for _, app := range ns.App {
+			if !app.HasRunningDeployment() {
+				continue
+			}
+			count, err := recoverInterrupted(ns.Name, app.Name)
+			if errors.Is(err, service.ErrAppBusy) {
+				continue
+			}
+			if err != nil {
+				log.Println("[recover][error]", ns.Name+"/"+app.Name, err)
+				continue
+			}
+			log.Printf("[recover] %s/%s marked %d interrupted deployments failed", ns.Name, app.Name, count)
+		}
+	}
+}
+
+// recoverInterrupted 获取应用锁后清理中断的部署记录，锁被占用时返回 service.ErrAppBusy
+func recoverInterrupted(namespace, name string) (int, error) {
+	unlock, err := service.TryLockApp(namespace, name)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	return service.RecoverInterrupted(namespace, name)
+}
+
+// ListDeployQueue daemon 当前运行中和排队中的部署
+func ListDeployQueue() ([]queue.Job, error) {
+	return queue.LoadSnapshot()
+}
+
+// AppHistory 应用的部署记录，最新的在前
+func AppHistory(nsName, appName string) ([]domain.Deployment, error) {
+	ns, err := domain.NewNamespace(nsName)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, ErrNamespaceNotFound
+	}
+
+	app, found := ns.FindApp(appName)
+	if !found {
+		return nil, ErrAppNotFound
+	}
+
+	history := make([]domain.Deployment, 0, len(app.History))
+	for i := len(app.History) - 1; i >= 0; i-- {
+		history = append(history, app.History[i])
+	}
+	return history, nil
+}
+
+type AppBuildLogOptions struct {
+	Namespace string
+	Name      string
+	Version   string // 为空时取最近一次构建
+	Out       io.Writer
+}
+
+// AppBuildLog 输出构建日志，构建仍在进行时持续跟踪直到结束、中断或 ctx 取消
+func AppBuildLog(ctx context.Context, opt AppBuildLogOptions) error {
+	record, err := findBuildRecord(opt.Namespace, opt.Name, opt.Version)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(record.BuildLog)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("build log of version [%s] not found", record.Version)
+		}
+		return err
+	}
+	defer file.Close()
+
+	for {
+		if _, err := io.Copy(opt.Out, file); err != nil {
+			return err
+		}
+		if record.Status != domain.DeploymentRunning {
+			if record.Error == domain.ErrorInterrupted {
+				return fmt.Errorf("build of version [%s] was interrupted", record.Version)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(buildLogPollInterval):
+		}
+
+		// 应用锁空闲说明部署进程已退出，记录停留在 running 是中断的部署，标记后不再跟踪
+		if _, err := recoverInterrupted(opt.Namespace, opt.Name); err != nil && !errors.Is(err, service.ErrAppBusy) {
+			return err
+		}
+
+		// 重新读取部署状态，结束后输出剩余内容
+		record, err = findDeploymentRecord(opt.Namespace, opt.Name, record.ID)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+const buildLogPollInterval = 500 * time.Millisecond
+
+func findBuildRecord(nsName, appName, version string) (domain.Deployment, error) {
+	history, err := AppHistory(nsName, appName)
+	if err != nil {
+		return domain.Deployment{}, err
+	}
+
+	for _, record := range history {
+		if record.BuildLog == "" {
+			continue
+		}
+		if version == "" || record.Version == version {
+			return record, nil
+		}
+	}
+
+	if version != "" {
+		return domain.Deployment{}, fmt.Errorf("no build of version [%s] found", version)
+	}
+	return domain.Deployment{}, fmt.Errorf("app [%s] has no build", appName)
+}
+
+func findDeploymentRecord(nsName, appName, id string) (domain.Deployment, error) {
+	history, err := AppHistory(nsName, appName)
+	if err != nil {
+		return domain.Deployment{}, err
+	}
+
+	record, found := lo.Find(history, func(d domain.Deployment) bool {
+		return d.ID == id
+	})
+	if !found {
+		return domain.Deployment{}, fmt.Errorf("deployment [%s] not found", id)
+	}
+	return record, nil
+}
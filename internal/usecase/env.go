@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/util"
+)
+
+// ListAppEnv secret 的值已隐藏
+func ListAppEnv(nsName, appName string) ([]domain.Env, error) {
+	ns, err := domain.NewNamespace(nsName)
+	if err != nil {
+		return nil, err
+	}
+
+	app, found := ns.FindApp(appName)
+	if !found {
+		return nil, ErrAppNotFound
+	}
+	return util.MaskEnvs(app.Envs), nil
+}
@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"dockflow/internal/config"
+	"dockflow/internal/domain"
+	"dockflow/internal/service"
+	"dockflow/internal/service/docker"
+	"dockflow/internal/service/traefik"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type GCKind string
+
+const (
+	GCContainer GCKind = "container"
+	GCImage     GCKind = "image"
+	GCDeploy    GCKind = "deploy"
+)
+
+type GCItem struct {
+	Namespace string `json:"namespace"`
+	App       string `json:"app"`
+	Version   string `json:"version"`
+	Kind      GCKind `json:"kind"`
+	Ref       string `json:"ref,omitempty"`    // 容器名 / 镜像 tag
+	Removed   bool   `json:"removed"`          // dry-run、跳过或删除失败时为 false
+	Detail    string `json:"detail,omitempty"` // 跳过或失败的原因
+}
+
+type GCOptions struct {
+	DryRun bool
+}
+
+type GCReport struct {
+	Items []GCItem `json:"items"`
+}
+
+func (r *GCReport) add(item GCItem) {
+	r.Items = append(r.Items, item)
+}
+
+// GC 按保留策略回收应用的旧版本：
+// - 超出保留数量的版本：删除部署记录、容器、路由与镜像
+// - dockflow 构建但没有部署记录的镜像（如构建成功、部署失败）：删除镜像
+// 当前运行的版本、回滚目标不会被回收；每个应用持有应用锁回收，正在部署（锁被占用）的应用跳过，
+// 锁空闲时残留的 running 部署记录来自中断的部署，标记为失败
+// 镜像仍被其他部署记录引用（不同 namespace 的同名应用）或仍被容器使用时保留
+func GC(opt GCOptions) (*GCReport, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &collector{
+		opt:         opt,
+		report:      &GCReport{Items: []GCItem{}},
+		defaultKeep: cfg.Daemon.KeepVersions,
+		used:        map[string]bool{},
+		removed:     map[string]bool{},
+	}
+
+	namespaces := domain.ListNamespaces()
+	for _, ns := range namespaces {
+		for _, app := range ns.App {
+			g.markUsed(app.Name, g.keptVersions(app))
+		}
+	}
+
+	for _, ns := range namespaces {
+		for _, app := range ns.App {
+			g.collect(ns.Name, app.Name)
+		}
+	}
+	return g.report, nil
+}
+
+type collector struct {
+	opt         GCOptions
+	report      *GCReport
+	defaultKeep int
+
+	used    map[string]bool // 保留的部署记录引用的镜像
+	removed map[string]bool // 本次已处理的镜像
+}
+
+// keptVersions 保留策略之外仍保留的部署记录
+func (g *collector) keptVersions(app domain.AppSpec) []domain.AppDeploy {
+	expired := app.ExpiredVersions(app.KeepVersions(g.defaultKeep))
+	return lo.Filter(app.Deploy, func(deploy domain.AppDeploy, _ int) bool {
+		return !lo.ContainsBy(expired, func(v domain.AppDeploy) bool { return v.Version == deploy.Version })
+	})
+}
+
+// collect 持有应用锁回收一个应用，删除容器与镜像期间不会有新的部署开始
+// 加锁后重新读取状态：快照之后新部署的版本、新构建的镜像都在锁内可见
+func (g *collector) collect(namespace, appName string) {
+	unlock, err := service.TryLockApp(namespace, appName)
+	if errors.Is(err, service.ErrAppBusy) {
+		return
+	}
+	if err != nil {
+		g.report.add(GCItem{Namespace: namespace, App: appName, Kind: GCDeploy, Detail: "lock: " + err.Error()})
+		return
+	}
+	defer unlock()
+
+	ns, err := domain.NewNamespace(namespace)
+	if err != nil {
+		g.report.add(GCItem{Namespace: namespace, App: appName, Kind: GCDeploy, Detail: "load state: " + err.Error()})
+		return
+	}
+	if ns == nil {
+		return
+	}
+	app, found := ns.FindApp(appName)
+	if !found {
+		return
+	}
+
+	// ---------- 中断的部署 ----------
+	if app.HasRunningDeployment() && !g.opt.DryRun {
+		if _, err := service.RecoverInterrupted(namespace, appName); err != nil {
+			g.report.add(GCItem{Namespace: namespace, App: appName, Kind: GCDeploy, Detail: "recover interrupted: " + err.Error()})
+			return
+		}
+	}
+
+	g.markUsed(appName, g.keptVersions(app))
+	if versions := app.ExpiredVersions(app.KeepVersions(g.defaultKeep)); len(versions) > 0 {
+		g.collectApp(namespace, app, versions)
+	}
+	g.collectOrphanImages(namespace, appName)
+}
+
+func (g *collector) markUsed(appName string, deploys []domain.AppDeploy) {
+	for _, deploy := range deploys {
+		g.used[deployImage(appName, deploy)] = true
+	}
+}
+
+// deployImage 部署记录对应的镜像，旧记录没有 Image 字段时按版本拼接
+func deployImage(appName string, deploy domain.AppDeploy) string {
+	if deploy.Image != "" || deploy.Version == "latest" {
+		return deploy.Image
+	}
+	return fmt.Sprintf("%s:%s", appName, deploy.Version)
+}
+
+// collectApp 回收一个应用的过期版本
+// 先在事务中删除部署记录，避免 reconcile 重建即将删除的容器；
+// 事务内按最新状态重新计算，期间被回滚使用的版本保留
+func (g *collector) collectApp(namespace string, app domain.AppSpec, versions []domain.AppDeploy) {
+	if !g.opt.DryRun {
+		var dropped []domain.AppDeploy
+		err := domain.UpdateNamespace(namespace, func(ns *domain.Namespace) error {
+			dropped = nil
+			_, index, found := lo.FindIndexOf(ns.App, func(a domain.AppSpec) bool { return a.Name == app.Name })
+			if !found {
+				return nil
+			}
+
+			current := &ns.App[index]
+			expired := current.ExpiredVersions(current.KeepVersions(g.defaultKeep))
+			dropped = lo.Filter(versions, func(v domain.AppDeploy, _ int) bool {
+				return lo.ContainsBy(expired, func(e domain.AppDeploy) bool { return e.Version == v.Version })
+			})
+			current.Deploy = lo.Filter(current.Deploy, func(deploy domain.AppDeploy, _ int) bool {
+				return !lo.ContainsBy(dropped, func(d domain.AppDeploy) bool { return d.Version == deploy.Version })
+			})
+			return nil
+		})
+		if err != nil {
+			g.report.add(GCItem{Namespace: namespace, App: app.Name, Kind: GCDeploy, Detail: "save state: " + err.Error()})
+			return
+		}
+		versions = dropped
+	}
+
+	for _, deploy := range versions {
+		item := GCItem{Namespace: namespace, App: app.Name, Version: deploy.Version}
+
+		// ---------- deploy ----------
+		deployItem := item
+		deployItem.Kind = GCDeploy
+		deployItem.Removed = !g.opt.DryRun
+		g.report.add(deployItem)
+
+		// ---------- container ----------
+		containerItem := item
+		containerItem.Kind = GCContainer
+		containerItem.Ref = fmt.Sprintf("%s_%s", app.Name, deploy.Version)
+		g.removeContainer(&containerItem, deploy.ContainerId)
+		g.report.add(containerItem)
+
+		// ---------- image ----------
+		imageItem := item
+		imageItem.Kind = GCImage
+		imageItem.Ref = deployImage(app.Name, deploy)
+		g.removeImage(&imageItem)
+		g.report.add(imageItem)
+	}
+}
+
+// removeContainer 按 ID 查找容器，ID 过期时按名称查找，并删除该版本的路由
+func (g *collector) removeContainer(item *GCItem, containerId string) {
+	id, err := docker.HasContainer(containerId)
+	if err == nil && id == "" {
+		id, err = docker.HasContainer(item.Ref)
+	}
+	if err != nil {
+		item.Detail = err.Error()
+		return
+	}
+	if id == "" {
+		item.Detail = "container not found"
+	}
+	if g.opt.DryRun {
+		return
+	}
+
+	if id != "" {
+		if err := docker.StopContainer(id, nil); err != nil {
+			item.Detail = "stop: " + err.Error()
+			return
+		}
+		if err := docker.RemoveContainer(id, true); err != nil {
+			item.Detail = "remove: " + err.Error()
+			return
+		}
+		item.Removed = true
+	}
+	if err := traefik.RemoveAppRoutes(item.App, item.Version); err != nil {
+		item.Detail = "remove routes: " + err.Error()
+	}
+}
+
+func (g *collector) removeImage(item *GCItem) {
+	if g.used[item.Ref] {
+		item.Detail = "referenced by another deploy"
+		return
+	}
+	g.removed[item.Ref] = true
+
+	exists, err := docker.ImageExists(item.Ref)
+	if err != nil {
+		item.Detail = err.Error()
+		return
+	}
+	if !exists {
+		item.Detail = "image not found"
+		return
+	}
+	if g.opt.DryRun {
+		return
+	}
+
+	if err := docker.RemoveImage(item.Ref); err != nil {
+		if errors.Is(err, docker.ErrImageInUse) {
+			item.Detail = "in use by a container"
+		} else {
+			item.Detail = err.Error()
+		}
+		return
+	}
+	item.Removed = true
+}
+
+// collectOrphanImages 删除 dockflow 为该应用构建、但没有部署记录引用的镜像
+// 只处理带 dockflow label 的镜像，不会误删同名的其他镜像
+func (g *collector) collectOrphanImages(namespace, appName string) {
+	images, err := docker.ListImagesByLabel(map[string]string{
+		domain.LabelNamespace: namespace,
+		domain.LabelName:      appName,
+	})
+	if err != nil {
+		g.report.add(GCItem{Namespace: namespace, App: appName, Kind: GCImage, Detail: "list images: " + err.Error()})
+		return
+	}
+
+	for _, image := range images {
+		for _, tag := range image.RepoTags {
+			if !strings.HasPrefix(tag, appName+":") || g.used[tag] || g.removed[tag] {
+				continue
+			}
+			item := GCItem{
+				Namespace: namespace,
+				App:       appName,
+				Version:   strings.TrimPrefix(tag, appName+":"),
+				Kind:      GCImage,
+				Ref:       tag,
+				Detail:    "no deploy entry",
+			}
+			g.removeImage(&item)
+			g.report.add(item)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"dockflow/internal/domain"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+var (
+	ErrResourceLinked = errors.New("resource is linked by apps, unlink it first")
+	ErrLinkNotFound   = errors.New("link not found")
+)
+
+type LinkAppOptions struct {
+	Namespace string
+	Name      string
+	Link      domain.AppLink
+	// Restart 使用当前镜像重建 latest 使连接信息生效，否则只标记需要重新部署
+	Restart bool
+}
+
+// LinkApp 链接 redis / database，已链接时更新前缀
+func LinkApp(opt LinkAppOptions) (*UpdateAppResult, error) {
+	if err := opt.Link.Validate(); err != nil {
+		return nil, err
+	}
+
+	return updateAppLinks(opt, func(ns *domain.Namespace, app *domain.AppSpec) error {
+		if err := checkLinkTarget(ns, opt.Link); err != nil {
+			return err
+		}
+
+		// 同类型的多个链接前缀相同时注入的变量会互相覆盖
+		conflict := lo.ContainsBy(app.Links, func(l domain.AppLink) bool {
+			return l.Kind == opt.Link.Kind && l.Name != opt.Link.Name && l.Prefix == opt.Link.Prefix
+		})
+		if conflict {
+			return fmt.Errorf("app already links another %s with the same prefix, use --prefix", opt.Link.Kind)
+		}
+
+		_, index, found := lo.FindIndexOf(app.Links, func(l domain.AppLink) bool {
+			return l.Kind == opt.Link.Kind && l.Name == opt.Link.Name
+		})
+		if found {
+			if app.Links[index] == opt.Link {
+				return nil
+			}
+			app.Links[index] = opt.Link
+		} else {
+			app.Links = append(app.Links, opt.Link)
+		}
+		app.MarkNeedsRedeploy()
+		return nil
+	})
+}
+
+func UnlinkApp(opt LinkAppOptions) (*UpdateAppResult, error) {
+	return updateAppLinks(opt, func(ns *domain.Namespace, app *domain.AppSpec) error {
+		_, index, found := lo.FindIndexOf(app.Links, func(l domain.AppLink) bool {
+			return l.Kind == opt.Link.Kind && l.Name == opt.Link.Name
+		})
+		if !found {
+			return fmt.Errorf("%w: %s/%s", ErrLinkNotFound, opt.Link.Kind, opt.Link.Name)
+		}
+		app.Links = append(app.Links[:index], app.Links[index+1:]...)
+		app.MarkNeedsRedeploy()
+		return nil
+	})
+}
+
+func updateAppLinks(opt LinkAppOptions, fn func(ns *domain.Namespace, app *domain.AppSpec) error) (*UpdateAppResult, error) {
+	var updated domain.AppSpec
+
+	err := domain.UpdateNamespace(opt.Namespace, func(ns *domain.Namespace) error {
+		_, index, found := lo.FindIndexOf(ns.App, func(app domain.AppSpec) bool {
+			return app.Name == opt.Name
+		})
+		if !found {
+			return ErrAppNotFound
+		}
+
+		if err := fn(ns, &ns.App[index]); err != nil {
+			return err
+		}
+		updated = ns.App[index]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restartUpdatedApp(updated, opt.Restart)
+}
+
+func checkLinkTarget(ns *domain.Namespace, link domain.AppLink) error {
+	switch link.Kind {
+	case domain.ResourceRedis:
+		if _, found := ns.FindRedis(link.Name); !found {
+			return ErrRedisNotExist
+		}
+	case domain.ResourceDatabase:
+		if _, found := ns.FindDatabase(link.Name); !found {
+			return ErrdatabaseNotExist
+		}
+	}
+	return nil
+}
+
+// checkNotLinked 删除 redis / database 前检查是否仍被 app 使用
+func checkNotLinked(ns *domain.Namespace, kind domain.ResourceKind, name string) error {
+	if apps := ns.LinkedApps(kind, name); len(apps) > 0 {
+		return fmt.Errorf("%w: %s", ErrResourceLinked, strings.Join(apps, ", "))
+	}
+	return nil
+}
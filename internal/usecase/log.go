@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"dockflow/internal/domain"
+	"dockflow/internal/service/docker"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/samber/lo"
+)
+
+type AppLogsOptions struct {
+	Namespace string
+	Name      string
+	Version   string // 为空时输出全部部署版本
+	Follow    bool
+	Tail      string
+	Since     string
+
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// AppLogs 输出应用容器日志，ctx 取消时（Ctrl-C）关闭所有日志流
+func AppLogs(ctx context.Context, opt AppLogsOptions) error {
+	ns, err := domain.NewNamespace(opt.Namespace)
+	if err != nil {
+		return err
+	}
+	if ns == nil {
+		return ErrNamespaceNotFound
+	}
+
+	app, found := ns.FindApp(opt.Name)
+	if !found {
+		return ErrAppNotFound
+	}
+
+	deploys := app.Deploy
+	if opt.Version != "" {
+		deploys = lo.Filter(deploys, func(d domain.AppDeploy, _ int) bool {
+			return d.Version == opt.Version
+		})
+		if len(deploys) == 0 {
+			return fmt.Errorf("app [%s] version [%s] not deployed", opt.Name, opt.Version)
+		}
+	}
+	if len(deploys) == 0 {
+		return fmt.Errorf("app [%s] has no deployment", opt.Name)
+	}
+
+	// 多个版本同时输出时，每行加上版本前缀
+	withPrefix := len(deploys) > 1
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(deploys))
+
+	streams := make([]io.ReadCloser, 0, len(deploys))
+	for _, deploy := range deploys {
+		rc, err := docker.ContainerLogs(deploy.ContainerId, docker.ContainerLogOptions{
+			Follow: opt.Follow,
+			Tail:   opt.Tail,
+			Since:  opt.Since,
+		})
+		if err != nil {
+			for _, opened := range streams {
+				opened.Close()
+			}
+			return fmt.Errorf("version [%s]: %w", deploy.Version, err)
+		}
+		streams = append(streams, rc)
+	}
+
+	for i, deploy := range deploys {
+		rc := streams[i]
+
+		prefix := ""
+		if withPrefix {
+			prefix = "[" + deploy.Version + "] "
+		}
+		stdout := &linePrefixWriter{mu: &mu, out: opt.Stdout, prefix: prefix}
+		stderr := &linePrefixWriter{mu: &mu, out: opt.Stderr, prefix: prefix}
+
+		wg.Add(1)
+		go func(i int, version string, rc io.ReadCloser) {
+			defer wg.Done()
+			defer rc.Close()
+
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				select {
+				case <-ctx.Done():
+					rc.Close()
+				case <-done:
+				}
+			}()
+
+			// 容器未开启 TTY，日志为 stdout/stderr 多路复用流
+			_, err := stdcopy.StdCopy(stdout, stderr, rc)
+			stdout.Flush()
+			stderr.Flush()
+			if err != nil && ctx.Err() == nil {
+				errs[i] = fmt.Errorf("version [%s]: %w", version, err)
+			}
+		}(i, deploy.Version, rc)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linePrefixWriter 按行写出，保证多个日志流并发输出时不会互相穿插
+type linePrefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *linePrefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx == -1 {
+			break
+		}
+		if err := w.writeLine(w.buf[:idx+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Flush 输出最后不以换行结尾的内容
+func (w *linePrefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	_ = w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+}
+
+func (w *linePrefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.prefix != "" {
+		if _, err := io.WriteString(w.out, w.prefix); err != nil {
+			return err
+		}
+	}
+	_, err := w.out.Write(line)
+	return err
+}
@@ -86,3 +86,14 @@ func RemoveNamespace(name string) error {
 	// 再删本地状态
 	return ns.Remove()
 }
+
+// ExportNamespace 导出可以直接 apply 的 manifest，敏感值以 ${VAR} 引用，vars 为引用的变量名
+func ExportNamespace(name string) (*domain.StackManifest, []string, error) {
+	ns, err := domain.NewNamespace(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, vars := domain.ExportStackManifest(*ns)
+	return &m, vars, nil
+}
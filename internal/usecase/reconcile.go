@@ -0,0 +1,482 @@
+package usecase
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service"
+	"dockflow/internal/service/docker"
+	"dockflow/internal/service/traefik"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+)
+
+type ReconcileAction string
+
+const (
+	ReconcileRecreated   ReconcileAction = "recreated"
+	ReconcileStarted     ReconcileAction = "started"
+	ReconcileUpdatedID   ReconcileAction = "updated-id"
+	ReconcileUpdatedIP   ReconcileAction = "updated-ip"
+	ReconcileReconnected ReconcileAction = "reconnected"
+	ReconcileOrphan      ReconcileAction = "orphan"
+	ReconcileInterrupted ReconcileAction = "interrupted"
+	ReconcileFailed      ReconcileAction = "failed"
+)
+
+type ReconcileEvent struct {
+	Namespace string              `json:"namespace"`
+	Kind      domain.ResourceKind `json:"kind"`
+	Name      string              `json:"name"`
+	Version   string              `json:"version,omitempty"`
+	Action    ReconcileAction     `json:"action"`
+	Detail    string              `json:"detail,omitempty"`
+	Applied   bool                `json:"applied"` // dry-run 或只报告的事件为 false
+}
+
+type ReconcileOptions struct {
+	DryRun bool
+}
+
+type ReconcileReport struct {
+	Events []ReconcileEvent `json:"events"`
+}
+
+func (r *ReconcileReport) add(event ReconcileEvent) {
+	r.Events = append(r.Events, event)
+}
+
+// Reconcile 对比 namespace 状态与 docker 中的容器：
+// - 容器缺失：按状态重新创建
+// - 容器已停止：启动
+// - 状态中的 ID 过期（同名容器被重建）：更新 ID
+// - 缺少网络：重新接入
+// - IP 变化：更新状态
+// - 带 dockflow.* label 但没有对应状态的容器：只报告，不删除
+// - 中断的部署留下的 running 记录：标记为失败
+// 正在部署的应用（应用锁被占用）跳过，避免与部署流程互相干扰；
+// 应用之间并发收敛，单个应用等待就绪不会阻塞其他应用
+func Reconcile(opt ReconcileOptions) (*ReconcileReport, error) {
+	containers, err := docker.ListContainers(true)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reconciler{
+		opt:    opt,
+		report: &ReconcileReport{Events: []ReconcileEvent{}},
+		byId:   map[string]types.Container{},
+		byName: map[string]types.Container{},
+		known:  map[string]bool{},
+		busy:   map[string]bool{},
+	}
+	for _, c := range containers {
+		r.byId[c.ID] = c
+		for _, name := range c.Names {
+			r.byName[strings.TrimPrefix(name, "/")] = c
+		}
+	}
+
+	for _, ns := range domain.ListNamespaces() {
+		r.reconcileNamespace(ns)
+	}
+
+	r.reportOrphans(containers)
+	return r.report, nil
+}
+
+type reconciler struct {
+	opt    ReconcileOptions
+	report *ReconcileReport
+
+	byId   map[string]types.Container
+	byName map[string]types.Container
+
+	mu    sync.Mutex      // 保护 report、known、busy，应用并发收敛
+	known map[string]bool // 有对应状态的容器
+	busy  map[string]bool // 正在部署的应用 namespace/name
+}
+
+func (r *reconciler) add(event ReconcileEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.add(event)
+}
+
+func (r *reconciler) markKnown(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.known[id] = true
+}
+
+func (r *reconciler) markBusy(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.busy[namespace+"/"+name] = true
+}
+
+// resource 一个需要收敛的容器
+type resource struct {
+	namespace     string
+	kind          domain.ResourceKind
+	name          string
+	version       string
+	containerId   string
+	containerName string
+	networks      []string
+	ips           []string // 状态中记录的 IP，app 不记录
+}
+
+func (r *reconciler) event(res resource, action ReconcileAction, detail string, applied bool) {
+	r.add(ReconcileEvent{
+		Namespace: res.namespace,
+		Kind:      res.kind,
+		Name:      res.name,
+		Version:   res.version,
+		Action:    action,
+		Detail:    detail,
+		Applied:   applied,
+	})
+}
+
+func (r *reconciler) reconcileNamespace(ns domain.Namespace) {
+	redisUpdates := map[string]domain.RedisSpec{}
+	databaseUpdates := map[string]domain.DatabaseSpec{}
+
+	// ---------- redis ----------
+	for _, redis := range ns.Redis {
+		res := resource{
+			namespace:     ns.Name,
+			kind:          domain.ResourceRedis,
+			name:          redis.Name,
+			containerId:   redis.ContainerId,
+			containerName: redis.Name,
+			networks:      []string{ns.Network},
+			ips:           redis.Ip,
+		}
+		id, ips, changed := r.converge(res, func() (string, []string, error) {
+			return runRedis(ns.Network, redis)
+		})
+		if changed {
+			redis.ContainerId = id
+			redis.Ip = ips
+			redisUpdates[redis.Name] = redis
+		}
+	}
+
+	// ---------- database ----------
+	for _, database := range ns.Database {
+		res := resource{
+			namespace:     ns.Name,
+			kind:          domain.ResourceDatabase,
+			name:          database.Name,
+			containerId:   database.ContainerId,
+			containerName: database.Name,
+			networks:      []string{ns.Network},
+			ips:           database.Ip,
+		}
+		id, ips, changed := r.converge(res, func() (string, []string, error) {
+			return runDatabase(ns.Network, database)
+		})
+		if changed {
+			database.ContainerId = id
+			database.Ip = ips
+			databaseUpdates[database.Name] = database
+		}
+	}
+
+	// ---------- app ----------
+	var wg sync.WaitGroup
+	for _, app := range ns.App {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.reconcileApp(ns, app)
+		}()
+	}
+	wg.Wait()
+
+	if r.opt.DryRun || (len(redisUpdates) == 0 && len(databaseUpdates) == 0) {
+		return
+	}
+
+	err := domain.UpdateNamespace(ns.Name, func(current *domain.Namespace) error {
+		for i, redis := range current.Redis {
+			if updated, ok := redisUpdates[redis.Name]; ok {
+				current.Redis[i].ContainerId = updated.ContainerId
+				current.Redis[i].Ip = updated.Ip
+			}
+		}
+		for i, database := range current.Database {
+			if updated, ok := databaseUpdates[database.Name]; ok {
+				current.Database[i].ContainerId = updated.ContainerId
+				current.Database[i].Ip = updated.Ip
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.add(ReconcileEvent{
+			Namespace: ns.Name,
+			Action:    ReconcileFailed,
+			Detail:    "save state: " + err.Error(),
+		})
+	}
+}
+
+// reconcileApp 持有应用锁收敛一个应用，锁被占用说明正在部署，跳过
+// 加锁后重新读取状态，快照之后有新的部署时容器列表已过期，留到下一轮收敛
+func (r *reconciler) reconcileApp(ns domain.Namespace, snapshot domain.AppSpec) {
+	appName := snapshot.Name
+	unlock, err := service.TryLockApp(ns.Name, appName)
+	if errors.Is(err, service.ErrAppBusy) {
+		r.markBusy(ns.Name, appName)
+		return
+	}
+	if err != nil {
+		r.add(ReconcileEvent{Namespace: ns.Name, Kind: domain.ResourceApp, Name: appName, Action: ReconcileFailed, Detail: "lock: " + err.Error()})
+		return
+	}
+	defer unlock()
+
+	current, err := domain.NewNamespace(ns.Name)
+	if err != nil {
+		r.add(ReconcileEvent{Namespace: ns.Name, Kind: domain.ResourceApp, Name: appName, Action: ReconcileFailed, Detail: "load state: " + err.Error()})
+		return
+	}
+	if current == nil {
+		return
+	}
+	app, found := current.FindApp(appName)
+	if !found {
+		return
+	}
+	if lastDeploymentID(app) != lastDeploymentID(snapshot) {
+		r.markBusy(ns.Name, appName)
+		return
+	}
+
+	// ---------- 中断的部署 ----------
+	if app.HasRunningDeployment() {
+		res := resource{namespace: ns.Name, kind: domain.ResourceApp, name: app.Name}
+		if r.opt.DryRun {
+			r.event(res, ReconcileInterrupted, "running deployment without lock holder", false)
+		} else if count, err := service.RecoverInterrupted(ns.Name, app.Name); err != nil {
+			r.event(res, ReconcileFailed, "recover interrupted: "+err.Error(), false)
+		} else {
+			r.event(res, ReconcileInterrupted, fmt.Sprintf("marked %d running deployments failed", count), true)
+		}
+	}
+
+	idUpdates := map[string]string{}
+	for _, deploy := range app.Deploy {
+		res := resource{
+			namespace:     ns.Name,
+			kind:          domain.ResourceApp,
+			name:          app.Name,
+			version:       deploy.Version,
+			containerId:   deploy.ContainerId,
+			containerName: fmt.Sprintf("%s_%s", app.Name, deploy.Version),
+			networks:      []string{traefik.TraefikNetwork, current.Network},
+		}
+
+		id, _, changed := r.converge(res, func() (string, []string, error) {
+			deployer, err := service.NewAppDeployer(&app)
+			if err != nil {
+				return "", nil, err
+			}
+			// Recreate 自己保存新 ID
+			id, err := deployer.Recreate(deploy)
+			return id, nil, err
+		})
+		if changed {
+			idUpdates[deploy.Version] = id
+		}
+	}
+
+	if r.opt.DryRun || len(idUpdates) == 0 {
+		return
+	}
+
+	err = domain.UpdateNamespace(ns.Name, func(current *domain.Namespace) error {
+		for i, a := range current.App {
+			if a.Name != app.Name {
+				continue
+			}
+			for j, deploy := range a.Deploy {
+				if id, ok := idUpdates[deploy.Version]; ok {
+					current.App[i].Deploy[j].ContainerId = id
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.add(ReconcileEvent{
+			Namespace: ns.Name,
+			Kind:      domain.ResourceApp,
+			Name:      app.Name,
+			Action:    ReconcileFailed,
+			Detail:    "save state: " + err.Error(),
+		})
+	}
+}
+
+func lastDeploymentID(app domain.AppSpec) string {
+	if len(app.History) == 0 {
+		return ""
+	}
+	return app.History[len(app.History)-1].ID
+}
+
+// converge 收敛单个容器，返回最终的容器 ID、IP 以及状态是否需要更新
+func (r *reconciler) converge(res resource, recreate func() (string, []string, error)) (string, []string, bool) {
+	c, found := r.byId[res.containerId]
+	changed := false
+
+	// ---------- 过期 ID ----------
+	if !found {
+		c, found = r.byName[res.containerName]
+		if found {
+			r.event(res, ReconcileUpdatedID, fmt.Sprintf("%s -> %s", shortId(res.containerId), shortId(c.ID)), !r.opt.DryRun)
+			changed = true
+		}
+	}
+
+	// ---------- 重建 ----------
+	if !found {
+		if r.opt.DryRun {
+			r.event(res, ReconcileRecreated, "container missing", false)
+			return "", nil, false
+		}
+		id, ips, err := recreate()
+		if id != "" {
+			r.markKnown(id)
+		}
+		if err != nil {
+			r.event(res, ReconcileFailed, "recreate: "+err.Error(), false)
+			// 容器已创建时仍然记录新 ID
+			return id, ips, id != "" && res.kind != domain.ResourceApp
+		}
+		r.event(res, ReconcileRecreated, "container missing, new id "+shortId(id), true)
+		return id, ips, res.kind != domain.ResourceApp
+	}
+
+	r.markKnown(c.ID)
+
+	// ---------- 启动 ----------
+	if c.State != "running" && c.State != "restarting" {
+		if r.opt.DryRun {
+			r.event(res, ReconcileStarted, "container "+c.State, false)
+		} else if err := docker.StartContainer(c.ID); err != nil {
+			r.event(res, ReconcileFailed, "start: "+err.Error(), false)
+		} else {
+			r.event(res, ReconcileStarted, "container was "+c.State, true)
+		}
+	}
+
+	// ---------- 网络 ----------
+	for _, network := range res.networks {
+		if c.NetworkSettings != nil {
+			if _, ok := c.NetworkSettings.Networks[network]; ok {
+				continue
+			}
+		}
+		if r.opt.DryRun {
+			r.event(res, ReconcileReconnected, "missing network "+network, false)
+			continue
+		}
+		if err := docker.ConnectNetwork(network, c.ID); err != nil {
+			r.event(res, ReconcileFailed, "connect "+network+": "+err.Error(), false)
+			continue
+		}
+		r.event(res, ReconcileReconnected, "connected "+network, true)
+	}
+
+	if res.kind == domain.ResourceApp || r.opt.DryRun {
+		return c.ID, nil, changed
+	}
+
+	// ---------- IP ----------
+	ips, err := containerIPs(c.ID)
+	if err != nil {
+		r.event(res, ReconcileFailed, "inspect: "+err.Error(), false)
+		return c.ID, nil, changed
+	}
+	if !changed {
+		if !sameIPs(res.ips, ips) {
+			r.event(res, ReconcileUpdatedIP, fmt.Sprintf("%v -> %v", res.ips, ips), true)
+			changed = true
+		}
+	}
+	return c.ID, ips, changed
+}
+
+func (r *reconciler) reportOrphans(containers []types.Container) {
+	for _, c := range containers {
+		namespace, ok := c.Labels[domain.LabelNamespace]
+		if !ok || r.known[c.ID] {
+			continue
+		}
+		name := c.Labels[domain.LabelName]
+		if r.busy[namespace+"/"+name] {
+			continue
+		}
+
+		kind := domain.ResourceKind(c.Labels[domain.LabelKind])
+		if kind == "" {
+			kind = domain.ResourceApp
+		}
+
+		containerName := ""
+		if len(c.Names) > 0 {
+			containerName = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		r.report.add(ReconcileEvent{
+			Namespace: namespace,
+			Kind:      kind,
+			Name:      name,
+			Version:   c.Labels[domain.LabelVersion],
+			Action:    ReconcileOrphan,
+			Detail:    fmt.Sprintf("container %s (%s) has no state entry", containerName, shortId(c.ID)),
+		})
+	}
+}
+
+func containerIPs(containerId string) ([]string, error) {
+	inspect, err := docker.InspectContainer(containerId)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []string{}
+	if inspect.NetworkSettings == nil {
+		return ips, nil
+	}
+	for _, net := range inspect.NetworkSettings.Networks {
+		ips = append(ips, net.IPAddress)
+	}
+	return ips, nil
+}
+
+func sameIPs(a, b []string) bool {
+	a = slices.Clone(a)
+	b = slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+func shortId(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	if id == "" {
+		return "-"
+	}
+	return id
+}
@@ -3,7 +3,10 @@ package usecase
 import (
 	"dockflow/internal/domain"
 	"dockflow/internal/service/docker"
+	"dockflow/internal/service/secret"
 	"errors"
+	"fmt"
+	"log"
 )
 
 var (
@@ -26,16 +29,64 @@ func CreateRedis(redis domain.RedisSpec) error {
 		return ErrRedisExist
 	}
 
+	redis.Password, err = sealSecret(resourceSecretName(redis.Namespace, domain.ResourceRedis, redis.Name), redis.Password)
+	if err != nil {
+		return err
+	}
+
+	containerId, ips, err := runRedis(ns.Network, redis)
+	if err != nil {
+		removeUntrackedContainer(containerId)
+		return err
+	}
+
+	redis.ContainerId = containerId
+	redis.Ip = ips
+
+	err = domain.UpdateNamespace(redis.Namespace, func(ns *domain.Namespace) error {
+		if current, _ := findRedisByName(ns, redis.Name); current != nil {
+			return ErrRedisExist
+		}
+		ns.Redis = append(ns.Redis, redis)
+		return nil
+	})
+	if err != nil {
+		removeUntrackedContainer(containerId)
+	}
+	return err
+}
+
+// removeUntrackedContainer 容器已启动但状态没有写入（如并发创建了同名资源）时删除容器，
+// 避免留下没有状态、reconcile 也不会处理的容器
+func removeUntrackedContainer(containerId string) {
+	if containerId == "" {
+		return
+	}
+	if err := docker.RemoveContainer(containerId, true); err != nil {
+		log.Println("[create] remove untracked container failed", shortId(containerId), err)
+	}
+}
+
+// runRedis 拉取镜像并启动 redis 容器，reconcile 重建容器时复用
+func runRedis(network string, redis domain.RedisSpec) (string, []string, error) {
 	redisImageName := "redis:" + redis.Version
 	if err := docker.PullImage(redisImageName); err != nil {
-		return err
+		return "", nil, err
 	}
 
 	opts := docker.NewRunOptions(redis.Name, redisImageName)
 
-	opts.WithNetwork(ns.Network)
+	opts.WithNetwork(network)
 	opts.WithCpu(redis.CPU)
 	opts.WithMemory(redis.Memory)
+	opts.WithLabel(domain.LabelNamespace, redis.Namespace)
+	opts.WithLabel(domain.LabelName, redis.Name)
+	opts.WithLabel(domain.LabelKind, string(domain.ResourceRedis))
+
+	password, err := secret.Resolve(redis.Password)
+	if err != nil {
+		return "", nil, fmt.Errorf("redis password: %w", err)
+	}
 
 	var aof = "yes"
 	if !redis.AOF {
@@ -44,7 +95,7 @@ func CreateRedis(redis domain.RedisSpec) error {
 	opts.WithCommand(
 		"redis-server",
 		"--requirepass",
-		redis.Password,
+		password,
 		"--appendonly",
 		aof,
 		"--maxmemory-policy",
@@ -53,25 +104,14 @@ func CreateRedis(redis domain.RedisSpec) error {
 
 	containerId, err := docker.RunContainer(opts)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
-	inspect, err := docker.InspectContainer(containerId)
+	ips, err := containerIPs(containerId)
 	if err != nil {
-		return err
+		return containerId, nil, err
 	}
-
-	ips := []string{}
-	for _, net := range inspect.NetworkSettings.Networks {
-		ips = append(ips, net.IPAddress)
-	}
-
-	redis.ContainerId = containerId
-	redis.Ip = ips
-	ns.Redis = append(ns.Redis, redis)
-	ns.Save()
-
-	return nil
+	return containerId, ips, nil
 }
 
 func ListRedis(namespaceName string) ([]domain.RedisSpec, error) {
@@ -99,34 +139,45 @@ func RemoveRedis(namespaceName string, redisContainerName string) error {
 	if redis == nil || index <= -1 {
 		return ErrRedisNotExist
 	}
-
-	containerId, err := docker.HasContainer(redis.ContainerId)
-	if err != nil {
+	if err := checkNotLinked(ns, domain.ResourceRedis, redisContainerName); err != nil {
 		return err
 	}
-	if containerId == "" {
-		return ErrRedisNotExist
-	}
 
-	isRun, err := docker.ContainerRunning(redis.ContainerId)
+	// 容器已被手动删除时只清理状态
+	containerId, err := docker.HasContainer(redis.ContainerId)
 	if err != nil {
 		return err
 	}
-	if isRun {
-		err := docker.StopContainer(redis.ContainerId, nil)
+	if containerId != "" {
+		isRun, err := docker.ContainerRunning(containerId)
+		if err != nil {
+			return err
+		}
+		if isRun {
+			err := docker.StopContainer(containerId, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = docker.RemoveContainer(containerId, true)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = docker.RemoveContainer(redis.ContainerId, true)
+	err = domain.UpdateNamespace(namespaceName, func(ns *domain.Namespace) error {
+		if _, index := findRedisByName(ns, redisContainerName); index > -1 {
+			ns.Redis = remove(ns.Redis, index)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	ns.Redis = remove(ns.Redis, index)
-	ns.Save()
-
+	redisNs := domain.Namespace{Name: namespaceName, Redis: []domain.RedisSpec{*redis}}
+	dropOwnedSecrets(resourceSecretName(namespaceName, domain.ResourceRedis, redis.Name), namespaceSecretFields(&redisNs))
 	return nil
 
 }
@@ -54,6 +54,10 @@ func RepoAdd(repo map[string]string) error {
 		return fmt.Errorf("repo [%s] not support", repo)
 	}
 
+	// token 加密保存，配置中只保留引用
+	if err := sealFields(configSecretFields(cfg)); err != nil {
+		return err
+	}
 	return config.Save(cfg)
 }
 
@@ -106,6 +110,10 @@ func RepoUpdate(repo map[string]string) error {
 		return fmt.Errorf("repo [%s] not support", repo)
 	}
 
+	// token 加密保存，配置中只保留引用
+	if err := sealFields(configSecretFields(cfg)); err != nil {
+		return err
+	}
 	return config.Save(cfg)
 }
 
@@ -114,6 +122,8 @@ func RepoRemove(repo map[string]string) error {
 	if err != nil {
 		return err
 	}
+	// lo.Filter 返回新的切片，removed 仍指向删除前的 token
+	removed := configSecretFields(cfg)
 
 	switch repo["repo"] {
 	case "github":
@@ -133,7 +143,16 @@ func RepoRemove(repo map[string]string) error {
 		return fmt.Errorf("repo [%s] not support", repo)
 	}
 
-	return config.Save(cfg)
+	if err := config.Save(cfg); err != nil {
+		return err
+	}
+
+	url := ""
+	if repo["repo"] == "gitlab" {
+		url = getGitlabHost(repo["url"])
+	}
+	dropOwnedSecrets(gitSecretName(repo["repo"], url, repo["name"]), removed)
+	return nil
 }
 
 func getGitlabHost(url string) string {
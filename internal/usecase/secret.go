@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"dockflow/internal/config"
+	"dockflow/internal/domain"
+	"dockflow/internal/service/secret"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrSecretInUse = errors.New("secret is referenced, use --force to remove it anyway")
+)
+
+//
+// ==========================
+// Secret Fields
+// ==========================
+//
+
+// secretField 可以保存为 secret 引用的字段
+type secretField struct {
+	Owner     string  // 展示用，如 app shop/web token
+	Name      string  // 自动加密保存时使用的 secret 名称
+	Value     *string // 明文或 secret://<name>
+	Sensitive bool    // 明文需要加密保存；普通 env 只可能引用 secret
+}
+
+func appSecretName(ns, app, field string) string {
+	return strings.Join([]string{secret.SanitizeName(ns), secret.SanitizeName(app), field}, ".")
+}
+
+func resourceSecretName(ns string, kind domain.ResourceKind, name string) string {
+	return strings.Join([]string{secret.SanitizeName(ns), string(kind), secret.SanitizeName(name), "password"}, ".")
+}
+
+func gitSecretName(repo, url, name string) string {
+	parts := []string{"git", repo}
+	if url != "" {
+		parts = append(parts, secret.SanitizeName(url))
+	}
+	return strings.Join(append(parts, secret.SanitizeName(name)), ".")
+}
+
+func namespaceSecretFields(ns *domain.Namespace) []secretField {
+	var fields []secretField
+	for i := range ns.App {
+		app := &ns.App[i]
+		owner := fmt.Sprintf("app %s/%s", ns.Name, app.Name)
+		fields = append(fields,
+			secretField{Owner: owner + " token", Name: appSecretName(ns.Name, app.Name, "token"), Value: &app.Token, Sensitive: true},
+			secretField{Owner: owner + " webhook secret", Name: appSecretName(ns.Name, app.Name, "webhook"), Value: &app.Secret, Sensitive: true},
+		)
+		for j := range app.Envs {
+			env := &app.Envs[j]
+			fields = append(fields, secretField{
+				Owner:     owner + " env " + env.Key,
+				Name:      appSecretName(ns.Name, app.Name, "env."+secret.SanitizeName(env.Key)),
+				Value:     &env.Value,
+				Sensitive: env.Secret,
+			})
+		}
+	}
+	for i := range ns.Redis {
+		redis := &ns.Redis[i]
+		fields = append(fields, secretField{
+			Owner:     fmt.Sprintf("redis %s/%s password", ns.Name, redis.Name),
+			Name:      resourceSecretName(ns.Name, domain.ResourceRedis, redis.Name),
+			Value:     &redis.Password,
+			Sensitive: true,
+		})
+	}
+	for i := range ns.Database {
+		database := &ns.Database[i]
+		fields = append(fields, secretField{
+			Owner:     fmt.Sprintf("database %s/%s password", ns.Name, database.Name),
+			Name:      resourceSecretName(ns.Name, domain.ResourceDatabase, database.Name),
+			Value:     &database.Password,
+			Sensitive: true,
+		})
+	}
+	return fields
+}
+
+func configSecretFields(cfg *config.Config) []secretField {
+	var fields []secretField
+	for i := range cfg.Git.Github {
+		t := &cfg.Git.Github[i]
+		fields = append(fields, secretField{Owner: "repo github/" + t.Name, Name: gitSecretName("github", "", t.Name), Value: &t.Token, Sensitive: true})
+	}
+	for i := range cfg.Git.Gitee {
+		t := &cfg.Git.Gitee[i]
+		fields = append(fields, secretField{Owner: "repo gitee/" + t.Name, Name: gitSecretName("gitee", "", t.Name), Value: &t.Token, Sensitive: true})
+	}
+	for i := range cfg.Git.Gitlab {
+		t := &cfg.Git.Gitlab[i]
+		fields = append(fields, secretField{Owner: "repo gitlab/" + t.Url + "/" + t.Name, Name: gitSecretName("gitlab", t.Url, t.Name), Value: &t.Token, Sensitive: true})
+	}
+	fields = append(fields, secretField{Owner: "daemon api_token", Value: &cfg.Daemon.APIToken})
+	return fields
+}
+
+// sealSecret 明文加密保存为 secret 并返回引用，空值和已经是引用的值原样返回
+func sealSecret(name, value string) (string, error) {
+	if value == "" || secret.IsRef(value) {
+		return value, nil
+	}
+	return secret.Set(name, value)
+}
+
+// sealFields 加密保存需要保护的明文字段，字段改为引用
+func sealFields(fields []secretField) error {
+	for _, field := range fields {
+		if !field.Sensitive {
+			continue
+		}
+		ref, err := sealSecret(field.Name, *field.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Owner, err)
+		}
+		*field.Value = ref
+	}
+	return nil
+}
+
+// sealAppSecrets 加密保存 app 的 token、webhook secret 与 secret env
+func sealAppSecrets(app *domain.AppSpec) error {
+	ns := domain.Namespace{Name: app.Namespace, App: []domain.AppSpec{*app}}
+	if err := sealFields(namespaceSecretFields(&ns)); err != nil {
+		return err
+	}
+	*app = ns.App[0]
+	return nil
+}
+
+// secretBackup 覆盖固定名称的 secret 之前保存的旧值，后续的 namespace 事务失败时恢复
+type secretBackup struct {
+	name    string
+	plain   string
+	existed bool
+}
+
+func backupSecret(name string) (secretBackup, error) {
+	exists, err := secret.Exists(name)
+	if err != nil || !exists {
+		return secretBackup{name: name}, err
+	}
+	plain, err := secret.Decrypt(name)
+	if err != nil {
+		return secretBackup{}, err
+	}
+	return secretBackup{name: name, plain: plain, existed: true}, nil
+}
+
+// restoreSecrets 按相反顺序恢复，恢复失败只能保留新值
+func restoreSecrets(backups []secretBackup) {
+	for i := len(backups) - 1; i >= 0; i-- {
+		backup := backups[i]
+		if backup.existed {
+			_, _ = secret.Set(backup.name, backup.plain)
+		} else {
+			_ = secret.Delete(backup.name)
+		}
+	}
+}
+
+// secretChanged 保存的值（可能是引用）与 manifest 中的明文是否不同，无法解密时视为不同
+func secretChanged(stored, plain string) bool {
+	same, err := secret.Matches(stored, plain)
+	return err != nil || !same
+}
+
+// dropOwnedSecrets 删除资源时清理自动创建的 secret，用户创建并引用的 secret 保留
+func dropOwnedSecrets(prefix string, fields []secretField) {
+	for _, field := range fields {
+		name, ok := secret.ParseRef(*field.Value)
+		if ok && name == field.Name && strings.HasPrefix(name, prefix) {
+			_ = secret.Delete(name)
+		}
+	}
+}
+
+//
+// ==========================
+// Commands
+// ==========================
+//
+
+type SecretInfo struct {
+	secret.Info
+	UsedBy []string `json:"usedBy"`
+}
+
+func SetSecret(name, value string) error {
+	if value == "" {
+		return errors.New("secret value is empty")
+	}
+	_, err := secret.Set(name, value)
+	return err
+}
+
+func ListSecrets() ([]SecretInfo, error) {
+	infos, err := secret.List()
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := secretReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]SecretInfo, 0, len(infos))
+	for _, info := range infos {
+		list = append(list, SecretInfo{Info: info, UsedBy: refs[info.Name]})
+	}
+	return list, nil
+}
+
+func RemoveSecret(name string, force bool) error {
+	exists, err := secret.Exists(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", secret.ErrSecretNotFound, name)
+	}
+
+	if !force {
+		refs, err := secretReferences()
+		if err != nil {
+			return err
+		}
+		if owners := refs[name]; len(owners) > 0 {
+			return fmt.Errorf("%w: %s", ErrSecretInUse, strings.Join(owners, ", "))
+		}
+	}
+	return secret.Delete(name)
+}
+
+// secretReferences secret 名称 → 引用它的字段
+func secretReferences() (map[string][]string, error) {
+	var fields []secretField
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	fields = append(fields, configSecretFields(cfg)...)
+
+	for _, ns := range domain.ListNamespaces() {
+		fields = append(fields, namespaceSecretFields(&ns)...)
+	}
+
+	refs := map[string][]string{}
+	for _, field := range fields {
+		if name, ok := secret.ParseRef(*field.Value); ok {
+			refs[name] = append(refs[name], field.Owner)
+		}
+	}
+	return refs, nil
+}
+
+//
+// ==========================
+// Migrate
+// ==========================
+//
+
+// MigrateSecrets 将 config 与 namespace 中的明文 token / 密码 / secret env 加密保存，原字段改为引用
+// 返回迁移的字段
+func MigrateSecrets() ([]string, error) {
+	var migrated []string
+
+	// ---------- config ----------
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	fields := configSecretFields(cfg)
+	plain := plainFields(fields)
+	if len(plain) > 0 {
+		if err := sealFields(fields); err != nil {
+			return migrated, err
+		}
+		if err := config.Save(cfg); err != nil {
+			return migrated, err
+		}
+		migrated = append(migrated, plain...)
+	}
+
+	// ---------- namespaces ----------
+	for _, ns := range domain.ListNamespaces() {
+		// 先加密保存，再在事务内替换仍为原明文的字段；期间被修改的字段留给下次迁移
+		sealed := map[string]string{}
+		for _, field := range namespaceSecretFields(&ns) {
+			if !field.Sensitive || *field.Value == "" || secret.IsRef(*field.Value) {
+				continue
+			}
+			if _, err := secret.Set(field.Name, *field.Value); err != nil {
+				return migrated, fmt.Errorf("%s: %w", field.Owner, err)
+			}
+			sealed[field.Name] = *field.Value
+		}
+		if len(sealed) == 0 {
+			continue
+		}
+
+		var owners []string
+		err := domain.UpdateNamespace(ns.Name, func(ns *domain.Namespace) error {
+			owners = nil
+			for _, field := range namespaceSecretFields(ns) {
+				value, ok := sealed[field.Name]
+				if ok && field.Sensitive && *field.Value == value {
+					*field.Value = secret.Ref(field.Name)
+					owners = append(owners, field.Owner)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return migrated, err
+		}
+		migrated = append(migrated, owners...)
+	}
+
+	sort.Strings(migrated)
+	return migrated, nil
+}
+
+// plainFields 需要加密但仍是明文的字段
+func plainFields(fields []secretField) []string {
+	var owners []string
+	for _, field := range fields {
+		if field.Sensitive && *field.Value != "" && !secret.IsRef(*field.Value) {
+			owners = append(owners, field.Owner)
+		}
+	}
+	return owners
+}
@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"dockflow/internal/state"
+)
+
+// MigrateState 将 yaml / json 文件中的状态迁移到内嵌数据库
+// 原文件保留作为备份，迁移完成后所有读写都走数据库
+func MigrateState() (state.MigrateResult, error) {
+	return state.MigrateToBolt()
+}
+
+// StateBackend 当前使用的状态存储
+func StateBackend() string {
+	return state.Default().Name()
+}
@@ -1 +1,171 @@
 package usecase
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service/docker"
+	"dockflow/internal/service/filesystem"
+	"dockflow/internal/service/traefik"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ContainerStateMissing = "missing"
+)
+
+type AppRoute struct {
+	Router  string   `json:"router"`
+	Rule    string   `json:"rule"`
+	Service string   `json:"service"`
+	Servers []string `json:"servers"`
+	TLS     bool     `json:"tls"`
+}
+
+type AppVersionStatus struct {
+	Version       string     `json:"version"`
+	ContainerId   string     `json:"containerId"`
+	ContainerName string     `json:"containerName"`
+	State         string     `json:"state"` // running / exited / missing ...
+	Health        string     `json:"health,omitempty"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	Uptime        string     `json:"uptime"`
+	RestartCount  int        `json:"restartCount"`
+	CPU           float64    `json:"cpu"`    // CPU cores
+	Memory        int64      `json:"memory"` // bytes
+	TraefikIp     string     `json:"traefikIp"`
+	NamespaceIp   string     `json:"namespaceIp"`
+	Routes        []AppRoute `json:"routes"`
+}
+
+type AppStatus struct {
+	Namespace string             `json:"namespace"`
+	Name      string             `json:"name"`
+	Healthy   bool               `json:"healthy"` // 所有记录的容器都存在且在运行
+	Versions  []AppVersionStatus `json:"versions"`
+}
+
+func GetAppStatus(nsName, appName string) (*AppStatus, error) {
+	ns, err := domain.NewNamespace(nsName)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, ErrNamespaceNotFound
+	}
+
+	app, found := ns.FindApp(appName)
+	if !found {
+		return nil, ErrAppNotFound
+	}
+
+	status := &AppStatus{
+		Namespace: ns.Name,
+		Name:      app.Name,
+		Healthy:   true,
+		Versions:  []AppVersionStatus{},
+	}
+
+	for _, deploy := range app.Deploy {
+		v, err := inspectAppVersion(ns, app, deploy)
+		if err != nil {
+			return nil, err
+		}
+		if v.State != "running" {
+			status.Healthy = false
+		}
+		status.Versions = append(status.Versions, v)
+	}
+
+	return status, nil
+}
+
+func inspectAppVersion(ns *domain.Namespace, app domain.AppSpec, deploy domain.AppDeploy) (AppVersionStatus, error) {
+	v := AppVersionStatus{
+		Version:     deploy.Version,
+		ContainerId: deploy.ContainerId,
+		State:       ContainerStateMissing,
+	}
+
+	routes, err := loadAppRoutes(app.Name, deploy.Version)
+	if err != nil {
+		return v, err
+	}
+	v.Routes = routes
+
+	containerId, err := docker.HasContainer(deploy.ContainerId)
+	if err != nil {
+		return v, err
+	}
+	if containerId == "" {
+		return v, nil
+	}
+
+	info, err := docker.InspectContainer(containerId)
+	if err != nil {
+		return v, err
+	}
+
+	v.ContainerName = strings.TrimPrefix(info.Name, "/")
+	v.RestartCount = info.RestartCount
+
+	if info.State != nil {
+		v.State = info.State.Status
+		if info.State.Health != nil {
+			v.Health = info.State.Health.Status
+		}
+		if info.State.Running {
+			if startedAt, err := time.Parse(time.RFC3339Nano, info.State.StartedAt); err == nil {
+				v.StartedAt = &startedAt
+				v.Uptime = time.Since(startedAt).Truncate(time.Second).String()
+			}
+		}
+	}
+
+	if info.HostConfig != nil {
+		v.CPU = float64(info.HostConfig.NanoCPUs) / 1e9
+		v.Memory = info.HostConfig.Memory
+	}
+
+	if info.NetworkSettings != nil {
+		for name, network := range info.NetworkSettings.Networks {
+			switch name {
+			case traefik.TraefikNetwork:
+				v.TraefikIp = network.IPAddress
+			case ns.Network:
+				v.NamespaceIp = network.IPAddress
+			}
+		}
+	}
+
+	return v, nil
+}
+
+func loadAppRoutes(appName, version string) ([]AppRoute, error) {
+	cfg, err := domain.NewTraefikConfig(filesystem.TraefikAppCfgFile(appName, version))
+	if err != nil {
+		return nil, fmt.Errorf("load traefik config: %w", err)
+	}
+
+	routes := []AppRoute{}
+	for name, router := range cfg.HTTP.Routers {
+		route := AppRoute{
+			Router:  name,
+			Rule:    router.Rule,
+			Service: router.Service,
+			TLS:     router.TLS != nil,
+		}
+		if svc, ok := cfg.HTTP.Services[router.Service]; ok && svc.LoadBalancer != nil {
+			for _, server := range svc.LoadBalancer.Servers {
+				route.Servers = append(route.Servers, server.URL)
+			}
+		}
+		routes = append(routes, route)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Router < routes[j].Router
+	})
+	return routes, nil
+}
@@ -1 +1,275 @@
 package usecase
+
+import (
+	"dockflow/internal/domain"
+	"dockflow/internal/service"
+	"dockflow/internal/service/secret"
+	"fmt"
+
+	"github.com/samber/lo"
+)
+
+// UpdateAppOptions 只修改设置了的字段，webhook、Secret、部署记录保持不变
+type UpdateAppOptions struct {
+	Namespace string
+	Name      string
+
+	CPU         *float64
+	Memory      *int
+	TriggerType string // 为空时不修改
+	TriggerRule string // 为空时不修改
+
+	KeepVersions *int // 0 表示使用 daemon 的默认值
+
+	SetEnvs    []domain.Env // 已存在的 key 覆盖，secret 标记保留
+	UnsetEnvs  []string
+	AddURLs    []domain.AppURL // 已存在的 host 覆盖端口
+	RemoveURLs []string        // host
+
+	// Restart 使用当前镜像重建 latest 使配置生效，否则只标记需要重新部署
+	Restart bool
+}
+
+type UpdateAppResult struct {
+	App       domain.AppSpec
+	Restarted bool
+	Version   string // 重启的版本
+}
+
+func UpdateApp(opt UpdateAppOptions) (*UpdateAppResult, error) {
+	var (
+		updated     domain.AppSpec
+		removedEnvs []domain.Env    // 删除的 env，事务提交后清理自动创建的 secret
+		routedURLs  []domain.AppURL // 删除 url 后仍保留路由的 url
+	)
+
+	// secret 需要在 namespace 事务之外写入，事务失败时恢复旧值
+	secretsChanged, backups, err := sealEnvUpdates(&opt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = domain.UpdateNamespace(opt.Namespace, func(ns *domain.Namespace) error {
+		_, index, found := lo.FindIndexOf(ns.App, func(app domain.AppSpec) bool {
+			return app.Name == opt.Name
+		})
+		if !found {
+			return ErrAppNotFound
+		}
+
+		app := ns.App[index]
+		current := ns.App[index]
+		runtimeChanged, err := applyAppUpdate(&app, opt)
+		if err != nil {
+			return err
+		}
+		runtimeChanged = runtimeChanged || secretsChanged
+		if err := validateAppSpec(app); err != nil {
+			return err
+		}
+
+		// trigger 只影响 webhook 匹配，不需要重启
+		if runtimeChanged {
+			app.MarkNeedsRedeploy()
+		}
+
+		ns.App[index] = app
+		updated = app
+		removedEnvs = lo.Filter(current.Envs, func(env domain.Env, _ int) bool {
+			return !lo.ContainsBy(app.Envs, func(e domain.Env) bool { return e.Key == env.Key })
+		})
+		routedURLs = lo.Filter(current.URLs, func(u domain.AppURL, _ int) bool {
+			return !lo.Contains(opt.RemoveURLs, u.Host)
+		})
+		return nil
+	})
+	if err != nil {
+		restoreSecrets(backups)
+		return nil, err
+	}
+
+	if len(removedEnvs) > 0 {
+		removedNs := domain.Namespace{Name: opt.Namespace, App: []domain.AppSpec{{Name: opt.Name, Envs: removedEnvs}}}
+		dropOwnedSecrets(appSecretName(opt.Namespace, opt.Name, "env."), namespaceSecretFields(&removedNs))
+	}
+
+	// 删除的 url 立即停止路由，不需要等重新部署
+	if len(opt.RemoveURLs) > 0 {
+		deploy, err := service.NewAppDeployer(&updated)
+		if err != nil {
+			return &UpdateAppResult{App: updated}, err
+		}
+		if err := deploy.RewriteRoutes(routedURLs); err != nil {
+			return &UpdateAppResult{App: updated}, fmt.Errorf("app updated but removing routes failed: %w", err)
+		}
+	}
+
+	return restartUpdatedApp(updated, opt.Restart)
+}
+
+// restartUpdatedApp restart 为 true 且 latest 与配置不一致时，使用当前镜像重建 latest
+func restartUpdatedApp(updated domain.AppSpec, restart bool) (*UpdateAppResult, error) {
+	result := &UpdateAppResult{App: updated}
+	if !restart || !updated.NeedsRedeploy {
+		return result, nil
+	}
+
+	deploy, err := service.NewAppDeployer(&updated)
+	if err != nil {
+		return result, err
+	}
+	version, err := deploy.Restart()
+	if err != nil {
+		return result, fmt.Errorf("app updated but restart failed: %w", err)
+	}
+
+	result.App = updated
+	result.Restarted = true
+	result.Version = version
+	return result, nil
+}
+
+// sealEnvUpdates 加密保存 secret env（新标记的或原本就是 secret 的），值改为引用
+// 引用名称固定，值是否变化需要在这里比较明文；返回被覆盖的 secret 的旧值，用于失败时恢复
+func sealEnvUpdates(opt *UpdateAppOptions) (bool, []secretBackup, error) {
+	if len(opt.SetEnvs) == 0 {
+		return false, nil, nil
+	}
+
+	ns, err := domain.NewNamespace(opt.Namespace)
+	if err != nil {
+		return false, nil, err
+	}
+	app, found := ns.FindApp(opt.Name)
+	if !found {
+		return false, nil, ErrAppNotFound
+	}
+
+	changed := false
+	var backups []secretBackup
+	for i, env := range opt.SetEnvs {
+		existing, found := lo.Find(app.Envs, func(e domain.Env) bool { return e.Key == env.Key })
+		if !env.Secret && !(found && existing.Secret) {
+			continue
+		}
+
+		if found && existing.Secret && !secretChanged(existing.Value, env.Value) {
+			opt.SetEnvs[i] = existing
+			continue
+		}
+
+		name := appSecretName(opt.Namespace, opt.Name, "env."+secret.SanitizeName(env.Key))
+		backup, err := backupSecret(name)
+		if err != nil {
+			restoreSecrets(backups)
+			return false, nil, err
+		}
+		ref, err := sealSecret(name, env.Value)
+		if err != nil {
+			restoreSecrets(backups)
+			return false, nil, err
+		}
+		if ref != env.Value {
+			backups = append(backups, backup)
+		}
+		opt.SetEnvs[i] = domain.Env{Key: env.Key, Value: ref, Secret: true}
+		changed = true
+	}
+	return changed, backups, nil
+}
+
+// applyAppUpdate 返回是否修改了影响运行中容器的配置
+func applyAppUpdate(app *domain.AppSpec, opt UpdateAppOptions) (bool, error) {
+	changed := false
+
+	// ---------- resources ----------
+	if opt.CPU != nil && *opt.CPU != app.CPU {
+		if *opt.CPU <= 0 {
+			return false, fmt.Errorf("invalid cpu: %v", *opt.CPU)
+		}
+		app.CPU = *opt.CPU
+		changed = true
+	}
+	if opt.Memory != nil && *opt.Memory != app.Memory {
+		if *opt.Memory <= 0 {
+			return false, fmt.Errorf("invalid memory: %d", *opt.Memory)
+		}
+		app.Memory = *opt.Memory
+		changed = true
+	}
+
+	// ---------- trigger ----------
+	if opt.TriggerType != "" {
+		app.Trigger.Type = opt.TriggerType
+	}
+	if opt.TriggerRule != "" {
+		app.Trigger.Rule = opt.TriggerRule
+	}
+
+	// ---------- retention ----------
+	// 只影响 gc，不需要重启
+	if opt.KeepVersions != nil {
+		switch {
+		case *opt.KeepVersions < 0:
+			return false, fmt.Errorf("invalid keep-versions: %d", *opt.KeepVersions)
+		case *opt.KeepVersions == 0:
+			app.Retention = nil
+		default:
+			app.Retention = &domain.Retention{KeepVersions: *opt.KeepVersions}
+		}
+	}
+
+	// ---------- env ----------
+	for _, key := range opt.UnsetEnvs {
+		_, index, found := lo.FindIndexOf(app.Envs, func(env domain.Env) bool {
+			return env.Key == key
+		})
+		if !found {
+			return false, fmt.Errorf("env [%s] not found", key)
+		}
+		app.Envs = append(app.Envs[:index], app.Envs[index+1:]...)
+		changed = true
+	}
+	for _, env := range opt.SetEnvs {
+		_, index, found := lo.FindIndexOf(app.Envs, func(e domain.Env) bool {
+			return e.Key == env.Key
+		})
+		if !found {
+			app.Envs = append(app.Envs, env)
+			changed = true
+			continue
+		}
+		// 已标记为 secret 的变量修改值后仍然是 secret
+		env.Secret = env.Secret || app.Envs[index].Secret
+		if app.Envs[index] != env {
+			app.Envs[index] = env
+			changed = true
+		}
+	}
+
+	// ---------- url ----------
+	for _, host := range opt.RemoveURLs {
+		_, index, found := lo.FindIndexOf(app.URLs, func(u domain.AppURL) bool {
+			return u.Host == host
+		})
+		if !found {
+			return false, fmt.Errorf("url [%s] not found", host)
+		}
+		app.URLs = append(app.URLs[:index], app.URLs[index+1:]...)
+		changed = true
+	}
+	for _, url := range opt.AddURLs {
+		_, index, found := lo.FindIndexOf(app.URLs, func(u domain.AppURL) bool {
+			return u.Host == url.Host
+		})
+		if !found {
+			app.URLs = append(app.URLs, url)
+			changed = true
+		} else if app.URLs[index].Port != url.Port {
+			app.URLs[index] = url
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
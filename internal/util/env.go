@@ -0,0 +1,114 @@
+package util
+
+import (
+	"bufio"
+	"dockflow/internal/domain"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidEnvKey 环境变量名只允许字母、数字和下划线，不能以数字开头
+func ValidEnvKey(key string) bool {
+	return envKeyPattern.MatchString(key)
+}
+
+// ParseDotEnv 解析 .env 文件
+// - 空行和 # 开头的行忽略，支持 export 前缀
+// - 双引号内支持 \n \t \" \\ 转义，单引号内原样保留
+// - 未加引号的值，空格后的 # 视为注释
+// - 重复的 key 以最后一个为准，保持首次出现的顺序
+func ParseDotEnv(r io.Reader) ([]domain.Env, error) {
+	var envs []domain.Env
+	index := map[string]int{}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, raw, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || !ValidEnvKey(key) {
+			return nil, fmt.Errorf("line %d: invalid env format (expect KEY=VALUE)", lineNo)
+		}
+
+		value, err := parseDotEnvValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		if i, ok := index[key]; ok {
+			envs[i].Value = value
+			continue
+		}
+		index[key] = len(envs)
+		envs = append(envs, domain.Env{Key: key, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return envs, nil
+}
+
+func parseDotEnvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch quote := raw[0]; quote {
+	case '"', '\'':
+		end := closingQuote(raw, quote)
+		if end == -1 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		// 引号后只允许注释
+		if rest := strings.TrimSpace(raw[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected characters after quoted value")
+		}
+		value := raw[1:end]
+		if quote == '"' {
+			value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value)
+		}
+		return value, nil
+	}
+
+	if idx := strings.Index(raw, " #"); idx != -1 {
+		raw = raw[:idx]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// closingQuote 结束引号的位置，双引号内跳过 \ 转义的字符，注释中的引号不会被当作结束
+func closingQuote(raw string, quote byte) int {
+	for i := 1; i < len(raw); i++ {
+		if quote == '"' && raw[i] == '\\' {
+			i++
+			continue
+		}
+		if raw[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// MaskEnvs 隐藏 Secret 的值，用于对外展示
+func MaskEnvs(envs []domain.Env) []domain.Env {
+	masked := make([]domain.Env, 0, len(envs))
+	for _, env := range envs {
+		if env.Secret {
+			env.Value = MaskSecret(env.Value)
+		}
+		masked = append(masked, env)
+	}
+	return masked
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"dockflow/internal/domain"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []domain.Env
+		wantErr bool
+	}{
+		{
+			name:  "plain",
+			input: "A=1\nB = two words \n",
+			want:  []domain.Env{{Key: "A", Value: "1"}, {Key: "B", Value: "two words"}},
+		},
+		{
+			name:  "blank lines and comments",
+			input: "\n# comment\n  # indented comment\nA=1\n",
+			want:  []domain.Env{{Key: "A", Value: "1"}},
+		},
+		{
+			name:  "export prefix",
+			input: "export A=1",
+			want:  []domain.Env{{Key: "A", Value: "1"}},
+		},
+		{
+			name:  "empty value",
+			input: "A=\nB=\"\"",
+			want:  []domain.Env{{Key: "A", Value: ""}, {Key: "B", Value: ""}},
+		},
+		{
+			name:  "inline comment after unquoted value",
+			input: "A=1 # comment\nB=a#b",
+			want:  []domain.Env{{Key: "A", Value: "1"}, {Key: "B", Value: "a#b"}},
+		},
+		{
+			name:  "double quotes with escapes",
+			input: `A="line1\nline2\t\"q\" \\"`,
+			want:  []domain.Env{{Key: "A", Value: "line1\nline2\t\"q\" \\"}},
+		},
+		{
+			name:  "single quotes keep raw value",
+			input: `A='a\nb # c'`,
+			want:  []domain.Env{{Key: "A", Value: `a\nb # c`}},
+		},
+		{
+			name:  "quoted value with comment",
+			input: `A="x y" # say "hi"` + "\nB='z' # it's",
+			want:  []domain.Env{{Key: "A", Value: "x y"}, {Key: "B", Value: "z"}},
+		},
+		{
+			name:  "equals sign in value",
+			input: "A=k=v",
+			want:  []domain.Env{{Key: "A", Value: "k=v"}},
+		},
+		{
+			name:  "duplicate key keeps first position",
+			input: "A=1\nB=2\nA=3",
+			want:  []domain.Env{{Key: "A", Value: "3"}, {Key: "B", Value: "2"}},
+		},
+		{
+			name:    "missing equals sign",
+			input:   "A",
+			wantErr: true,
+		},
+		{
+			name:    "invalid key",
+			input:   "1A=1",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			input:   `A="abc`,
+			wantErr: true,
+		},
+		{
+			name:    "escaped closing quote only",
+			input:   `A="abc\"`,
+			wantErr: true,
+		},
+		{
+			name:    "characters after quoted value",
+			input:   `A="abc" def`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotEnv(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,3 +1,17 @@
 package util
 
+import "dockflow/internal/service/secret"
+
 func StrPtr(s string) *string { return &s }
+
+// MaskSecret 只保留末尾 4 位，用于对外展示 token / 密码
+// secret 引用本身不包含敏感信息，原样展示
+func MaskSecret(s string) string {
+	if s == "" || secret.IsRef(s) {
+		return s
+	}
+	if len(s) <= 4 {
+		return "****"
+	}
+	return "****" + s[len(s)-4:]
+}